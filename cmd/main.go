@@ -56,6 +56,7 @@ type ResponseMessage struct {
 	Content     string                 `json:"content"`
 	Tag         string                 `json:"tag"`
 	Information map[string]interface{} `json:"information"`
+	Locale      string                 `json:"locale"`
 }
 
 type Configuration struct {
@@ -82,7 +83,7 @@ func NewClient(host string, ssl bool, information *map[string]interface{}) (*Cli
 	}
 	client := &Client{ // Create a pointer to Client
 		Information: information,
-		Locale:      "en",
+		Locale:      "", // The server detects the language until one is chosen with /lang
 		Token:       generateToken(),
 		Connection:  connection,
 		Channel:     make(chan string),
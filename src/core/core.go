@@ -2,21 +2,41 @@ package olivia
 
 // =================================================================
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"hash/crc32"
+	"hash/fnv"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gookit/color"
 	"github.com/gorilla/mux"
 	"golang.org/x/oauth2"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 
+	"maps"
 	"math"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -24,8 +44,10 @@ import (
 	"encoding/csv"
 	"errors"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"strconv"
+	"text/tabwriter"
 
 	gocache "github.com/patrickmn/go-cache"
 	"github.com/soudy/mathcat"
@@ -52,8 +74,7 @@ func FetchFileContent(filePath string) (fileContent []byte) {
 }
 
 func GenerateSerializedMessages(region string) []DataPacket {
-	var parsedData []DataPacket
-	deserializationError := json.Unmarshal(FetchFileContent("../res/locales/"+region+"/messages.json"), &parsedData)
+	parsedData, deserializationError := ReadMessages(region)
 	if deserializationError != nil {
 		fmt.Println(deserializationError)
 	}
@@ -63,6 +84,30 @@ func GenerateSerializedMessages(region string) []DataPacket {
 	return parsedData
 }
 
+// ReadMessages reads ../res/locales/<locale>/messages.json and checks that each of its messages
+// has a tag
+func ReadMessages(locale string) (messages []DataPacket, err error) {
+	content, err := os.ReadFile(resourcePath("../res/locales/" + locale + "/messages.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(content, &messages); err != nil {
+		return nil, fmt.Errorf("the messages of %q are invalid: %w", locale, err)
+	}
+
+	for i, message := range messages {
+		if message.Label == "" {
+			return nil, fmt.Errorf("the message %d of %q has no tag", i, locale)
+		}
+
+		// The empty texts keep the positions of the ones which aren't translated
+		messages[i].Content = slices.DeleteFunc(message.Content, isEmptyText)
+	}
+
+	return messages, nil
+}
+
 func RetrieveCachedMessages(region string) []DataPacket {
 	return cachedDataStore[region]
 }
@@ -80,20 +125,23 @@ func FindMessageByLabel(identifier, region string) DataPacket {
 }
 
 func SelectRandomMessage(region, identifier string) string {
-	for _, item := range cachedDataStore[region] {
-		// Find the message with the right tag
-		if item.Label != identifier {
-			continue
-		}
+	// The messages which aren't translated are taken from the next locales of the chain
+	for _, locale := range LocaleChain(region) {
+		for _, item := range cachedDataStore[locale] {
+			// Find the message with the right tag, the next locale has the texts which aren't
+			// translated yet
+			if item.Label != identifier || len(item.Content) == 0 {
+				continue
+			}
 
-		// Returns the only element if there aren't more
-		if len(item.Content) == 1 {
-			return item.Content[0]
-		}
+			// Returns the only element if there aren't more
+			if len(item.Content) == 1 {
+				return item.Content[0]
+			}
 
-		// Returns a random sentence
-		rand.New(rand.NewSource(time.Now().UnixNano())) // depress: rand.Seed(time.Now().UnixNano())
-		return item.Content[rand.Intn(len(item.Content))]
+			// Returns a random sentence
+			return item.Content[ResponseRandom(len(item.Content))]
+		}
 	}
 
 	return ""
@@ -157,61 +205,343 @@ func RetrieveUserProfile(authToken string) UserProfile {
 	return cachedUserData[authToken]
 }
 
-func trainDataMain(locale string) (inputs, outputs [][]float64) {
+func trainDataMain(locale string) (words, classes []string, inputs, outputs Matrix) {
 	words, classes, documents := Organize(locale)
+	context := CompileNLPContext(locale, words, classes)
+
+	var inputRows, outputRows [][]float64
 
 	for _, document := range documents {
 		outputRow := make([]float64, len(classes))
-		bag := document.Sentence.WordsBag(words)
+		bag := context.WordsBag(document.Sentence)
 
 		// Change value to 1 where there is the document Tag
 		outputRow[SliceIndex(classes, document.Tag)] = 1
 
 		// Append data to inputs and outputs
-		inputs = append(inputs, bag)
-		outputs = append(outputs, outputRow)
+		inputRows = append(inputRows, bag)
+		outputRows = append(outputRows, outputRow)
 	}
 
-	return inputs, outputs
+	return words, classes, NewMatrix(inputRows), NewMatrix(outputRows)
 }
 
 func CreateNeuralNetwork(locale string, ignoreTrainingFile bool) (neuralNetwork Network) {
 	// Decide if the network is created by the save or is a new one
-	saveFile := "../res/locales/" + locale + "/training.json"
+	saveFile := ModelPath(locale)
+
+	_, err := os.Stat(saveFile)
+	if err == nil && !ignoreTrainingFile {
+		fmt.Printf(
+			"%s %s\n",
+			color.FgBlue.Render("Loading the neural network from"),
+			color.FgRed.Render(saveFile),
+		)
+		loadedNetwork, err := LoadNetwork(saveFile)
+		if err == nil {
+			RegisterNLPContext(loadedNetwork.SetContext(SerializeIntents(locale)))
+			return *loadedNetwork
+		}
+
+		// Re-train the model if the saved one can't be used
+		fmt.Println(color.FgRed.Render(err.Error()))
+	}
 
-	_, err := os.Open(saveFile)
 	// Train the model if there is no training file
-	if err != nil || ignoreTrainingFile {
-		inputs, outputs := trainDataMain(locale)
+	words, classes, inputs, outputs := trainDataMain(locale)
+	neuralNetwork = trainAndSaveNetwork(locale, words, classes, inputs, outputs)
 
-		neuralNetwork = CreateNetwork(locale, 0.1, inputs, outputs, 50)
-		neuralNetwork.Train(200)
+	RegisterNLPContext(neuralNetwork.SetContext(SerializeIntents(locale)))
 
-		// Save the neural network in ../res/training.json
-		neuralNetwork.Save(saveFile)
-	} else {
+	return
+}
+
+// trainAndSaveNetwork trains the network of the locale on its training data and saves it in
+// ../res/locales/<locale>/
+func trainAndSaveNetwork(locale string, words, classes []string, inputs, outputs Matrix) (neuralNetwork Network) {
+	neuralNetwork = TrainNetwork(locale, GetTrainingConfig(locale), inputs, outputs)
+	neuralNetwork.Words, neuralNetwork.Classes = words, classes
+	neuralNetwork.DataHash = HashTrainingData(words, classes, inputs, outputs)
+	neuralNetwork.CreatedAt = time.Now().UTC()
+
+	if err := neuralNetwork.Save(ModelPath(locale)); err != nil {
+		fmt.Println(color.FgRed.Render(err.Error()))
+	}
+
+	return
+}
+
+// TrainNetwork creates a network with the given configuration and trains it, the training matrices
+// are dropped once it is trained.
+func TrainNetwork(locale string, config TrainingConfig, inputs, outputs Matrix) (neuralNetwork Network) {
+	// Pick a seed when none is given so that the model can still be reproduced
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 		fmt.Printf(
 			"%s %s\n",
-			color.FgBlue.Render("Loading the neural network from"),
-			color.FgRed.Render(saveFile),
+			color.FgBlue.Render("Training with the random seed"),
+			color.FgRed.Render(strconv.FormatInt(seed, 10)),
 		)
-		// Initialize the intents
-		SerializeIntents(locale)
-		neuralNetwork = *LoadNetwork(saveFile)
 	}
+	random := rand.New(rand.NewSource(seed))
+
+	inputs, outputs, validationInputs, validationOutputs := SplitTrainingData(inputs, outputs, config.ValidationSplit, random)
+
+	neuralNetwork = CreateNetwork(locale, config.Rate, random, inputs, outputs, config.HiddenLayers...)
+	neuralNetwork.Seed = seed
+	if err := neuralNetwork.SetOutputActivation(config.OutputActivation, config.Loss); err != nil {
+		panic(err)
+	}
+	neuralNetwork.Optimizer = config.Optimizer
+	neuralNetwork.BatchSize = config.BatchSize
+	neuralNetwork.Shuffle = config.Shuffle
+	neuralNetwork.ValidationSplit = config.ValidationSplit
+	neuralNetwork.ValidationInput, neuralNetwork.ValidationOutput = validationInputs, validationOutputs
+	neuralNetwork.Patience = config.Patience
+	neuralNetwork.Dropout = config.Dropout
+	neuralNetwork.WeightDecay = config.WeightDecay
+	neuralNetwork.GradientClipping = config.GradientClipping
+	neuralNetwork.Augmentation = config.Augmentation
+	neuralNetwork.Train(config.Epochs)
+
+	// Drop the training matrices, only the weights are needed to predict
+	neuralNetwork.Layers, neuralNetwork.Output = nil, Matrix{}
+	neuralNetwork.ValidationInput, neuralNetwork.ValidationOutput = Matrix{}, Matrix{}
+	neuralNetwork.derivatives, neuralNetwork.dropoutMasks, neuralNetwork.random = nil, nil, nil
 
 	return
 }
 
+// CrossValidate trains a new network for each of the k folds of the locale's documents and
+// evaluates it on the fold which was kept aside.
+func CrossValidate(locale string, folds int) CrossValidationReport {
+	_, classes, inputs, outputs := trainDataMain(locale)
+	config := GetTrainingConfig(locale)
+
+	confusionMatrix := make([][]int, len(classes))
+	for i := range confusionMatrix {
+		confusionMatrix[i] = make([]int, len(classes))
+	}
+
+	foldsIndexes := SplitFolds(outputs, folds)
+	for fold, indexes := range foldsIndexes {
+		fmt.Printf("\n%s %d/%d\n", color.FgBlue.Render("Cross-validation fold"), fold+1, folds)
+
+		// Train on all the other folds
+		var trainingIndexes []int
+		for otherFold, otherIndexes := range foldsIndexes {
+			if otherFold != fold {
+				trainingIndexes = append(trainingIndexes, otherIndexes...)
+			}
+		}
+
+		neuralNetwork := TrainNetwork(
+			locale, config,
+			SelectRows(inputs, trainingIndexes), SelectRows(outputs, trainingIndexes),
+		)
+
+		predictions := neuralNetwork.Forward(SelectRows(inputs, indexes))
+		for i, index := range indexes {
+			confusionMatrix[ArgMax(outputs.Row(index))][ArgMax(predictions.Row(i))]++
+		}
+	}
+
+	return NewCrossValidationReport(locale, folds, classes, confusionMatrix)
+}
+
+// SplitFolds distributes the samples of each class between the folds, the distribution is the
+// same for the same outputs.
+func SplitFolds(outputs Matrix, folds int) [][]int {
+	foldsIndexes := make([][]int, folds)
+
+	classesCount := map[int]int{}
+	for i := 0; i < Rows(outputs); i++ {
+		class := ArgMax(outputs.Row(i))
+
+		// Rotate the first fold of each class to spread the classes with few samples
+		fold := (classesCount[class] + class) % folds
+		foldsIndexes[fold] = append(foldsIndexes[fold], i)
+		classesCount[class]++
+	}
+
+	return foldsIndexes
+}
+
+func NewCrossValidationReport(locale string, folds int, classes []string, confusionMatrix [][]int) CrossValidationReport {
+	report := CrossValidationReport{
+		Locale:          locale,
+		Folds:           folds,
+		Classes:         classes,
+		ConfusionMatrix: confusionMatrix,
+	}
+
+	var correct, total int
+	for i, class := range classes {
+		var predicted, expected int
+		for j := range classes {
+			predicted += confusionMatrix[j][i]
+			expected += confusionMatrix[i][j]
+		}
+
+		metrics := IntentMetrics{
+			Tag:     class,
+			Support: expected,
+		}
+		if predicted > 0 {
+			metrics.Precision = float64(confusionMatrix[i][i]) / float64(predicted)
+		}
+		if expected > 0 {
+			metrics.Recall = float64(confusionMatrix[i][i]) / float64(expected)
+		}
+		if metrics.Precision+metrics.Recall > 0 {
+			metrics.F1 = 2 * metrics.Precision * metrics.Recall / (metrics.Precision + metrics.Recall)
+		}
+
+		report.Intents = append(report.Intents, metrics)
+		correct += confusionMatrix[i][i]
+		total += expected
+	}
+
+	if total > 0 {
+		report.Accuracy = float64(correct) / float64(total)
+	}
+
+	return report
+}
+
+// Print writes the metrics of each intent, the confusions between intents and the confusion matrix
+// whose rows are the expected intents and columns the predicted ones.
+func (report CrossValidationReport) Print(output io.Writer) {
+	fmt.Fprintf(
+		output, "\n%s %s - %d folds - accuracy %s\n\n",
+		color.FgBlue.Render("Cross-validation of the"),
+		color.FgRed.Render(GetNameByTag(report.Locale)),
+		report.Folds,
+		color.FgGreen.Render(fmt.Sprintf("%.3f", report.Accuracy)),
+	)
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "#\tINTENT\tPRECISION\tRECALL\tF1\tSUPPORT")
+	for i, metrics := range report.Intents {
+		fmt.Fprintf(
+			writer, "%d\t%s\t%.3f\t%.3f\t%.3f\t%d\n",
+			i, metrics.Tag, metrics.Precision, metrics.Recall, metrics.F1, metrics.Support,
+		)
+	}
+	writer.Flush()
+
+	fmt.Fprintf(output, "\n%s\n", color.FgBlue.Render("Confusions (expected → predicted):"))
+	for i, row := range report.ConfusionMatrix {
+		for j, count := range row {
+			if i != j && count > 0 {
+				fmt.Fprintf(output, "  %s → %s: %d\n", report.Classes[i], report.Classes[j], count)
+			}
+		}
+	}
+
+	fmt.Fprintf(output, "\n%s\n", color.FgBlue.Render("Confusion matrix:"))
+	writer = tabwriter.NewWriter(output, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(writer, "\t")
+	for j := range report.Classes {
+		fmt.Fprintf(writer, "%d\t", j)
+	}
+	fmt.Fprintln(writer)
+	for i, row := range report.ConfusionMatrix {
+		fmt.Fprintf(writer, "%d\t", i)
+		for _, count := range row {
+			fmt.Fprintf(writer, "%d\t", count)
+		}
+		fmt.Fprintln(writer)
+	}
+	writer.Flush()
+}
+
+// SplitTrainingData keeps the given part of the samples of each class aside for the validation,
+// at least one sample of each class stays in the training set.
+func SplitTrainingData(inputs, outputs Matrix, split float64, random *rand.Rand) (trainingInputs, trainingOutputs, validationInputs, validationOutputs Matrix) {
+	if split <= 0 {
+		return inputs, outputs, Matrix{}, Matrix{}
+	}
+
+	// Group the samples by class
+	samplesByClass := map[int][]int{}
+	var classesOrder, trainingIndexes, validationIndexes []int
+	for i := 0; i < Rows(outputs); i++ {
+		class := ArgMax(outputs.Row(i))
+		if _, exists := samplesByClass[class]; !exists {
+			classesOrder = append(classesOrder, class)
+		}
+
+		samplesByClass[class] = append(samplesByClass[class], i)
+	}
+
+	for _, class := range classesOrder {
+		samples := samplesByClass[class]
+		random.Shuffle(len(samples), func(a, b int) {
+			samples[a], samples[b] = samples[b], samples[a]
+		})
+
+		validationCount := min(int(float64(len(samples))*split), len(samples)-1)
+		for k, sample := range samples {
+			if k < validationCount {
+				validationIndexes = append(validationIndexes, sample)
+				continue
+			}
+
+			trainingIndexes = append(trainingIndexes, sample)
+		}
+	}
+
+	return SelectRows(inputs, trainingIndexes), SelectRows(outputs, trainingIndexes),
+		SelectRows(inputs, validationIndexes), SelectRows(outputs, validationIndexes)
+}
+
+// ModelPath returns the file of the locale's model, the binary one is used when it exists
+func ModelPath(locale string) string {
+	binaryFile := "../res/locales/" + locale + "/training.model"
+	if _, err := os.Stat(binaryFile); err == nil {
+		return binaryFile
+	}
+
+	return "../res/locales/" + locale + "/training.json"
+}
+
+// HashTrainingData returns a SHA-256 of the data used to train a model
+func HashTrainingData(words, classes []string, inputs, outputs Matrix) string {
+	hash := sha256.New()
+
+	for _, list := range [][]string{words, classes} {
+		hash.Write([]byte(strings.Join(list, "\n")))
+		hash.Write([]byte{0})
+	}
+
+	for _, matrix := range []Matrix{inputs, outputs} {
+		binary.Write(hash, binary.LittleEndian, matrix.data)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func GetTrainingConfig(locale string) TrainingConfig {
+	if config, exists := TrainingConfigs[locale]; exists {
+		return config
+	}
+
+	return DefaultTrainingConfig
+}
+
 func EncodeDashboardData(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	params := mux.Vars(r)
 
+	resourcesMutex.RLock()
 	dashboardData := DashboardData{
 		NetworkLayers: GetNetworkLayers(params["locale"]),
 		TrainingInfo:  GetTrainingInfo(params["locale"]),
 	}
+	resourcesMutex.RUnlock()
 
 	if err := json.NewEncoder(w).Encode(dashboardData); err != nil {
 		log.Fatal(err)
@@ -220,12 +550,12 @@ func EncodeDashboardData(w http.ResponseWriter, r *http.Request) {
 
 func GetNetworkLayers(locale string) NetworkLayersData {
 	return NetworkLayersData{
-		// Get the number of rows of the first layer to get the count of input nodes
-		InputCount: Rows(globalNeuralNetworks[locale].Layers[0]),
-		// Get the number of hidden layers by removing the count of the input and output layers
-		HiddenCount: len(globalNeuralNetworks[locale].Layers) - 2,
-		// Get the number of rows of the latest layer to get the count of output nodes
-		OutputCount: Columns(globalNeuralNetworks[locale].Output),
+		// Get the number of rows of the first weights to get the count of input nodes
+		InputCount: Rows(globalNeuralNetworks[locale].Weights[0]),
+		// Get the number of hidden layers by removing the output layer
+		HiddenCount: len(globalNeuralNetworks[locale].Weights) - 1,
+		// Get the number of columns of the latest weights to get the count of output nodes
+		OutputCount: Columns(globalNeuralNetworks[locale].Weights[len(globalNeuralNetworks[locale].Weights)-1]),
 	}
 }
 
@@ -235,6 +565,21 @@ func GetTrainingInfo(locale string) TrainingInfoData {
 		LearningRate: globalNeuralNetworks[locale].Rate,
 		ErrorMetrics: globalNeuralNetworks[locale].Errors,
 		TrainingTime: globalNeuralNetworks[locale].Time,
+		Optimizer:    globalNeuralNetworks[locale].GetOptimizer(),
+		BatchSize:    globalNeuralNetworks[locale].BatchSize,
+
+		ValidationSplit:    globalNeuralNetworks[locale].ValidationSplit,
+		Epochs:             globalNeuralNetworks[locale].Epochs,
+		BestEpoch:          globalNeuralNetworks[locale].BestEpoch,
+		TrainingLoss:       globalNeuralNetworks[locale].TrainingLoss,
+		TrainingAccuracy:   globalNeuralNetworks[locale].TrainingAccuracy,
+		ValidationLoss:     globalNeuralNetworks[locale].ValidationLoss,
+		ValidationAccuracy: globalNeuralNetworks[locale].ValidationAccuracy,
+		Dropout:            globalNeuralNetworks[locale].Dropout,
+		WeightDecay:        globalNeuralNetworks[locale].WeightDecay,
+		GradientClipping:   globalNeuralNetworks[locale].GradientClipping,
+		Seed:               globalNeuralNetworks[locale].Seed,
+		Augmentation:       globalNeuralNetworks[locale].Augmentation,
 	}
 }
 
@@ -254,6 +599,10 @@ func StartServer(neuralNetworkInstances map[string]Network, serverPort string) {
 	router.HandleFunc("/api/{locale}/train", TrainNeuralNetwork).Methods("POST")
 	router.HandleFunc("/api/{locale}/intents", GetIntents).Methods("GET")
 	router.HandleFunc("/api/coverage", GetCoverage).Methods("GET")
+	router.HandleFunc("/api/reloads", GetReloads).Methods("GET")
+
+	// Reload the locales when their files are edited
+	go WatchResources(ResourcesWatchInterval)
 
 	magentaColor := color.FgMagenta.Render
 	fmt.Printf("\nServer listening on the port %s...\n", magentaColor(serverPort))
@@ -277,8 +626,12 @@ func TrainNeuralNetwork(w http.ResponseWriter, r *http.Request) {
 	magentaColor := color.FgMagenta.Render
 	fmt.Printf("\nRe-training the %s..\n", magentaColor("neural network"))
 
-	for locale := range globalNeuralNetworks {
-		globalNeuralNetworks[locale] = CreateNeuralNetwork(locale, true)
+	resourcesMutex.RLock()
+	locales := slices.Collect(maps.Keys(globalNeuralNetworks))
+	resourcesMutex.RUnlock()
+
+	for _, locale := range locales {
+		retrain(locale)
 	}
 }
 
@@ -286,6 +639,9 @@ func HandleWebSocketConnection(w http.ResponseWriter, r *http.Request) {
 	conn, _ := websocketUpgrader.Upgrade(w, r, nil)
 	fmt.Println(color.FgGreen.Render("A new connection has been opened"))
 
+	// The languages of the browser are used when the client doesn't choose a locale
+	acceptLanguage := r.Header.Get("Accept-Language")
+
 	for {
 		// Read message from browser
 		msgType, msg, err := conn.ReadMessage()
@@ -299,6 +655,10 @@ func HandleWebSocketConnection(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if request.Locale == "" {
+			request.Locale = acceptLanguage
+		}
+
 		// Set the information from the client into the cache
 		if reflect.DeepEqual(RetrieveUserProfile(request.Token), UserProfile{}) {
 			StoreUserProfile(request.Token, request.Information)
@@ -306,9 +666,17 @@ func HandleWebSocketConnection(w http.ResponseWriter, r *http.Request) {
 
 		// If the type of requests is a handshake then execute the start modules
 		if request.Type == 0 {
-			ExecuteModules(request.Token, request.Locale)
+			resourcesMutex.RLock()
+			// There is no message to detect the language from yet
+			locale := NegotiateLocale(request.Locale)
+			if locale == "" {
+				locale = DefaultLocale
+			}
 
+			ExecuteModules(request.Token, locale)
 			message := GetMessage()
+			resourcesMutex.RUnlock()
+
 			if message != "" {
 				// Generate the response to send to the user
 				response := serverResponseMessage{
@@ -340,21 +708,40 @@ func HandleWebSocketConnection(w http.ResponseWriter, r *http.Request) {
 
 func generateReply(request clientRequestMessage) []byte {
 	var responseSentence, responseTag string
+	var results []Result
+	var slots map[string]string
+	var detected *Result
+	var replaceable bool
+
+	// The resources can't be swapped by a reload while the intent and the response are chosen
+	resourcesMutex.RLock()
+
+	// Resolve the tags asked by the client through their fallback chains, e.g. fr-CA → fr → en
+	locale := NegotiateLocale(request.Locale)
 
 	// Send a message from ../res/datasets/messages.json if it is too long
 	if len(request.Content) > 500 {
+		if locale == "" {
+			locale = DefaultLocale
+		}
+
 		responseTag = "too long"
-		responseSentence = SelectRandomMessage(request.Locale, responseTag) // Keeping SelectRandomMessage as is
+		responseSentence = SelectRandomMessage(locale, responseTag) // Keeping SelectRandomMessage as is
 	} else {
-		// If the given locale is not supported yet, set english
-		locale := request.Locale
-		if !Exists(locale) { // Keeping Exists as is
-			locale = "en"
-		}
+		// Detect the language when the locale is missing, not supported, or overridden
+		locale, detected = DetectLocale(locale, request.Content)
 
-		responseTag, responseSentence = NewSentence(
+		responseTag, responseSentence, results, slots, replaceable = NewSentence(
 			locale, request.Content,
-		).Calculate(*cacheInstance, globalNeuralNetworks[locale], request.Token) // Keeping NewSentence and Calculate as is
+		).calculate(*cacheInstance, globalNeuralNetworks[locale], request.Token)
+	}
+	resourcesMutex.RUnlock()
+
+	// The modules can wait for a web service, they don't keep the reloads from swapping the networks
+	if replaceable {
+		tablesMutex.RLock()
+		responseTag, responseSentence = ReplaceContentf(locale, responseTag, request.Content, responseSentence, request.Token)
+		tablesMutex.RUnlock()
 	}
 
 	// Marshall the response in json
@@ -362,6 +749,15 @@ func generateReply(request clientRequestMessage) []byte {
 		Content:     responseSentence,
 		Tag:         responseTag,
 		Information: RetrieveUserProfile(request.Token),
+		Slots:       slots,
+		Locale:      locale,
+		Detected:    detected,
+	}
+
+	// Send the confidence and the best intents to the clients which ask for them
+	if request.Alternatives > 0 && len(results) > 0 {
+		response.Confidence = &results[0].Value
+		response.Alternatives = results[:min(request.Alternatives, len(results))]
 	}
 
 	bytes, err := json.Marshal(response)
@@ -372,552 +768,2948 @@ func generateReply(request clientRequestMessage) []byte {
 	return bytes
 }
 
-func (network Network) CalculateFinalLayerDerivatives() LayerDerivative {
+// CalculateFinalLayerDerivatives computes the derivatives of the output layer in derivative, its
+// matrices are reused between the steps
+func (network Network) CalculateFinalLayerDerivatives(derivative *LayerDerivative) error {
 	l := len(network.Layers) - 1
 	lastLayer := network.Layers[l]
 
-	// Compute derivative for the last layer of weights and biases
-	cost := Difference(network.Output, lastLayer)
-	sigmoidDerivative := Multiplication(lastLayer, ApplyFunction(lastLayer, SubtractsOne))
+	// The derivative of the cross-entropy through a softmax (or sigmoid) output is the cost itself
+	if err := DifferenceInto(&derivative.Delta, network.Output, lastLayer); err != nil {
+		return err
+	}
 
-	// Compute delta and the weights' adjustment
-	delta := Multiplication(
-		ApplyFunction(cost, MultipliesByTwo),
-		sigmoidDerivative,
-	)
-	weights := DotProduct(Transpose(network.Layers[l-1]), delta)
+	if network.GetLoss() == MeanSquaredLoss {
+		ApplyFunctionInto(&derivative.activation, lastLayer, func(y float64) float64 {
+			return MultipliesByTwo(SigmoidDerivative(y))
+		})
 
-	return LayerDerivative{
-		Delta:      delta,
-		Adjustment: weights,
+		if err := MultiplicationInto(&derivative.Delta, derivative.Delta, derivative.activation); err != nil {
+			return err
+		}
 	}
+
+	return network.calculateAdjustments(l, derivative)
 }
 
-func (network Network) CalculateLayerDerivatives(i int, derivatives []LayerDerivative) LayerDerivative {
+// CalculateLayerDerivatives computes the derivatives of the hidden layer which precedes the one of
+// derivatives[i] in derivatives[i+1]
+func (network Network) CalculateLayerDerivatives(i int, derivatives []LayerDerivative) error {
 	l := len(network.Layers) - 2 - i
+	derivative := &derivatives[i+1]
 
 	// Compute derivative for the layer of weights and biases
-	delta := Multiplication(
-		DotProduct(
-			derivatives[i].Delta,
-			Transpose(network.Weights[l]),
-		),
-		Multiplication(
-			network.Layers[l],
-			ApplyFunction(network.Layers[l], SubtractsOne),
-		),
-	)
-	weights := DotProduct(Transpose(network.Layers[l-1]), delta)
-
-	return LayerDerivative{
-		Delta:      delta,
-		Adjustment: weights,
+	if err := DotProductTransposedInto(&derivative.Delta, derivatives[i].Delta, network.Weights[l]); err != nil {
+		return err
 	}
-}
 
-func (network Network) ApplyAdjustments(derivatives []LayerDerivative) {
-	for i, derivative := range derivatives {
-		l := len(derivatives) - i
+	activationDerivative := network.GetActivation(l - 1).Derivative
+	ApplyFunctionInto(&derivative.activation, network.Layers[l], activationDerivative)
 
-		network.Weights[l-1] = Sum(
-			network.Weights[l-1],
-			ApplyRate(derivative.Adjustment, network.Rate),
-		)
-		network.Biases[l-1] = Sum(
-			network.Biases[l-1],
-			ApplyRate(derivative.Delta, network.Rate),
-		)
+	// The kept units were scaled, their derivative is taken at their activated value
+	if l < len(network.dropoutMasks) && Rows(network.dropoutMasks[l]) > 0 {
+		for k, scale := range network.dropoutMasks[l].data {
+			derivative.activation.data[k] = 0
+			if scale != 0 {
+				derivative.activation.data[k] = activationDerivative(network.Layers[l].data[k]/scale) * scale
+			}
+		}
 	}
-}
 
-func Sigmoid(x float64) float64 {
-	return 1 / (1 + math.Exp(-x))
-}
+	if err := MultiplicationInto(&derivative.Delta, derivative.Delta, derivative.activation); err != nil {
+		return err
+	}
 
-func MultipliesByTwo(x float64) float64 {
-	return 2 * x
+	return network.calculateAdjustments(l, derivative)
 }
 
-func SubtractsOne(x float64) float64 {
-	return x - 1
+// calculateAdjustments computes the adjustments of the weights and biases which lead to the l-th
+// layer from its delta
+func (network Network) calculateAdjustments(l int, derivative *LayerDerivative) error {
+	if err := TransposedDotProductInto(&derivative.Adjustment, network.Layers[l-1], derivative.Delta); err != nil {
+		return err
+	}
+
+	// The bias row is shared by all the samples so its adjustment is the sum of the deltas
+	SumRowsInto(&derivative.BiasAdjustment, derivative.Delta)
+
+	// The adjustments are summed over the samples, those of a batch are scaled to stand for the whole
+	// training set so that the full batch trains as before and the rate doesn't depend on the batch size
+	if batchSamples := Rows(derivative.Delta); network.samples > batchSamples {
+		scale := float64(network.samples) / float64(batchSamples)
+		ApplyRate(derivative.Adjustment, scale)
+		ApplyRate(derivative.BiasAdjustment, scale)
+	}
+
+	return nil
 }
 
-func RandomMatrix(rows, columns int) (matrix Matrix) {
-	matrix = make(Matrix, rows)
+func (network Network) ApplyAdjustments(derivatives []LayerDerivative, optimizer Optimizer) {
+	var parameters, adjustments []Matrix
 
-	for i := 0; i < rows; i++ {
-		matrix[i] = make([]float64, columns)
-		for j := 0; j < columns; j++ {
-			matrix[i][j] = rand.Float64()*2.0 - 1.0
+	for i, derivative := range derivatives {
+		l := len(derivatives) - i
+
+		// The L2 penalty pulls the weights towards zero, the biases aren't penalized
+		if network.WeightDecay > 0 {
+			for k, weight := range network.Weights[l-1].data {
+				derivative.Adjustment.data[k] -= network.WeightDecay * weight
+			}
 		}
+
+		parameters = append(parameters, network.Weights[l-1], network.Biases[l-1])
+		adjustments = append(adjustments, derivative.Adjustment, derivative.BiasAdjustment)
 	}
 
-	return
+	if network.GradientClipping > 0 {
+		ClipAdjustments(adjustments, network.GradientClipping)
+	}
+
+	optimizer.Update(parameters, adjustments)
 }
 
-func CreateMatrix(rows, columns int) (matrix Matrix) {
-	matrix = make(Matrix, rows)
+// ClipAdjustments scales the adjustments down when their global norm is above maxNorm
+func ClipAdjustments(adjustments []Matrix, maxNorm float64) {
+	var sum float64
+	for _, adjustment := range adjustments {
+		for _, x := range adjustment.data {
+			sum += x * x
+		}
+	}
 
-	for i := 0; i < rows; i++ {
-		matrix[i] = make([]float64, columns)
+	norm := math.Sqrt(sum)
+	if norm <= maxNorm {
+		return
 	}
 
-	return
+	for _, adjustment := range adjustments {
+		ApplyRate(adjustment, maxNorm/norm)
+	}
 }
 
-func Rows(matrix Matrix) int {
-	return len(matrix)
-}
+func NewOptimizer(name string, rate float64) (Optimizer, error) {
+	switch name {
+	case SGDOptimizer, "":
+		return &SGD{Rate: rate}, nil
+	case MomentumOptimizer:
+		return &Momentum{Rate: rate, Momentum: 0.9}, nil
+	case AdamOptimizer:
+		return &Adam{Rate: rate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}, nil
+	}
 
-func Columns(matrix Matrix) int {
-	return len(matrix[0])
+	return nil, fmt.Errorf("unknown optimizer %q", name)
 }
 
-func ApplyFunctionWithIndex(matrix Matrix, fn func(i, j int, x float64) float64) Matrix {
-	for i := 0; i < Rows(matrix); i++ {
-		for j := 0; j < Columns(matrix); j++ {
-			matrix[i][j] = fn(i, j, matrix[i][j])
+func (optimizer *SGD) Update(parameters, adjustments []Matrix) {
+	for k, parameter := range parameters {
+		for i, adjustment := range adjustments[k].data {
+			parameter.data[i] += optimizer.Rate * adjustment
 		}
 	}
-
-	return matrix
 }
 
-func ApplyFunction(matrix Matrix, fn func(x float64) float64) Matrix {
-	return ApplyFunctionWithIndex(matrix, func(i, j int, x float64) float64 {
-		return fn(x)
-	})
-}
+func (optimizer *Momentum) Update(parameters, adjustments []Matrix) {
+	// Initialize the velocities at the first update
+	if optimizer.velocities == nil {
+		optimizer.velocities = CreateMatricesLike(parameters)
+	}
 
-func ApplyRate(matrix Matrix, rate float64) Matrix {
-	return ApplyFunction(matrix, func(x float64) float64 {
-		return rate * x
-	})
+	for k, parameter := range parameters {
+		velocity := optimizer.velocities[k].data
+
+		for i, adjustment := range adjustments[k].data {
+			velocity[i] = optimizer.Momentum*velocity[i] + optimizer.Rate*adjustment
+			parameter.data[i] += velocity[i]
+		}
+	}
 }
 
-func DotProduct(matrix, matrix2 Matrix) Matrix {
-	if Columns(matrix) != Rows(matrix2) {
-		panic("Cannot make dot product between these two matrix.")
+func (optimizer *Adam) Update(parameters, adjustments []Matrix) {
+	// Initialize the moments at the first update
+	if optimizer.moments == nil {
+		optimizer.moments = CreateMatricesLike(parameters)
+		optimizer.squares = CreateMatricesLike(parameters)
 	}
 
-	return ApplyFunctionWithIndex(
-		CreateMatrix(Rows(matrix), Columns(matrix2)),
-		func(i, j int, x float64) float64 {
-			var sum float64
+	optimizer.step++
+	// Correct the bias of the moments which are initialized at zero
+	correction1 := 1 - math.Pow(optimizer.Beta1, float64(optimizer.step))
+	correction2 := 1 - math.Pow(optimizer.Beta2, float64(optimizer.step))
 
-			for k := 0; k < Columns(matrix); k++ {
-				sum += matrix[i][k] * matrix2[k][j]
-			}
+	for k, parameter := range parameters {
+		moment, square := optimizer.moments[k].data, optimizer.squares[k].data
 
-			return sum
-		},
-	)
+		for i, adjustment := range adjustments[k].data {
+			moment[i] = optimizer.Beta1*moment[i] + (1-optimizer.Beta1)*adjustment
+			square[i] = optimizer.Beta2*square[i] + (1-optimizer.Beta2)*adjustment*adjustment
+
+			parameter.data[i] += optimizer.Rate * (moment[i] / correction1) /
+				(math.Sqrt(square[i]/correction2) + optimizer.Epsilon)
+		}
+	}
 }
 
-func Sum(matrix, matrix2 Matrix) (resultMatrix Matrix) {
-	ErrorNotSameSize(matrix, matrix2)
+func Sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
 
-	CreateMatrix(Rows(matrix), Columns(matrix))
+func SigmoidDerivative(y float64) float64 {
+	return y * (1 - y)
+}
 
-	return ApplyFunctionWithIndex(matrix, func(i, j int, x float64) float64 {
-		return matrix[i][j] + matrix2[i][j]
-	})
+func TanhDerivative(y float64) float64 {
+	return 1 - y*y
 }
 
-func Difference(matrix, matrix2 Matrix) (resultMatrix Matrix) {
-	ErrorNotSameSize(matrix, matrix2)
+func ReLU(x float64) float64 {
+	return math.Max(0, x)
+}
 
-	CreateMatrix(Rows(matrix), Columns(matrix))
+func ReLUDerivative(y float64) float64 {
+	if y > 0 {
+		return 1
+	}
 
-	return ApplyFunctionWithIndex(resultMatrix, func(i, j int, x float64) float64 {
-		return matrix[i][j] - matrix2[i][j]
-	})
+	return 0
 }
 
-func Multiplication(matrix, matrix2 Matrix) (resultMatrix Matrix) {
-	ErrorNotSameSize(matrix, matrix2)
-
-	CreateMatrix(Rows(matrix), Columns(matrix))
+func LeakyReLU(x float64) float64 {
+	if x > 0 {
+		return x
+	}
 
-	return ApplyFunctionWithIndex(matrix, func(i, j int, x float64) float64 {
-		return matrix[i][j] * matrix2[i][j]
-	})
+	return 0.01 * x
 }
 
-func Transpose(matrix Matrix) (resultMatrix Matrix) {
-	resultMatrix = CreateMatrix(Columns(matrix), Rows(matrix))
+func LeakyReLUDerivative(y float64) float64 {
+	if y > 0 {
+		return 1
+	}
+
+	return 0.01
+}
 
+// Softmax normalizes each row of the matrix into probabilities which sum to one
+func Softmax(matrix Matrix) Matrix {
 	for i := 0; i < Rows(matrix); i++ {
-		for j := 0; j < Columns(matrix); j++ {
-			resultMatrix[j][i] = matrix[i][j]
+		row := matrix.Row(i)
+
+		// Subtract the maximum of the row to avoid overflows of the exponential
+		max := math.Inf(-1)
+		for _, x := range row {
+			max = math.Max(max, x)
+		}
+
+		var sum float64
+		for j, x := range row {
+			row[j] = math.Exp(x - max)
+			sum += row[j]
+		}
+
+		for j := range row {
+			row[j] /= sum
 		}
 	}
 
-	return resultMatrix
+	return matrix
 }
 
-func ErrorNotSameSize(matrix, matrix2 Matrix) {
-	if Rows(matrix) != Rows(matrix2) && Columns(matrix) != Columns(matrix2) {
-		panic("These two matrices must have the same dimension.")
-	}
+func MultipliesByTwo(x float64) float64 {
+	return 2 * x
 }
 
-func LoadNetwork(fileName string) *Network {
-	inF, err := os.Open(fileName)
-	if err != nil {
-		panic("Failed to load " + fileName + ".")
+// RandomMatrix creates a matrix filled with uniform values in [-limit, limit]
+func RandomMatrix(rows, columns int, limit float64, random *rand.Rand) Matrix {
+	matrix := CreateMatrix(rows, columns)
+
+	for i := range matrix.data {
+		matrix.data[i] = (random.Float64()*2.0 - 1.0) * limit
 	}
-	defer inF.Close()
 
-	decoder := json.NewDecoder(inF)
-	neuralNetwork := &Network{}
-	err = decoder.Decode(neuralNetwork)
-	if err != nil {
-		panic(err)
+	return matrix
+}
+
+// InitializeWeights creates the weights between two layers with the Xavier method, or the He method
+// for the rectifiers
+func InitializeWeights(rows, columns int, activation Activation, random *rand.Rand) Matrix {
+	if activation.He {
+		return RandomMatrix(rows, columns, math.Sqrt(6/float64(rows)), random)
 	}
 
-	return neuralNetwork
+	return RandomMatrix(rows, columns, math.Sqrt(6/float64(rows+columns)), random)
 }
 
-func CreateNetwork(locale string, rate float64, input, output Matrix, hiddensNodes ...int) Network {
-	input = append([][]float64{
-		make([]float64, len(input[0])),
-	}, input...)
-	output = append([][]float64{
-		make([]float64, len(output[0])),
-	}, output...)
+// CreateMatrix creates a matrix of zeros, its rows are stored one after the other in a single slice
+func CreateMatrix(rows, columns int) Matrix {
+	return Matrix{rows: rows, columns: columns, data: make([]float64, rows*columns)}
+}
 
-	// Create the layers arrays and add the input values
-	inputMatrix := input
-	layers := []Matrix{inputMatrix}
-	// Generate the hidden layer
-	for _, hiddenNodes := range hiddensNodes {
-		layers = append(layers, CreateMatrix(len(input), hiddenNodes))
+// NewMatrix creates a matrix from a slice of rows, the values are copied
+func NewMatrix(rows [][]float64) Matrix {
+	if len(rows) == 0 {
+		return Matrix{}
 	}
-	// Add the output values to the layers arrays
-	layers = append(layers, output)
 
-	// Generate the weights and biases
-	weightsNumber := len(layers) - 1
-	var weights []Matrix
-	var biases []Matrix
+	matrix := CreateMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		copy(matrix.Row(i), row)
+	}
+
+	return matrix
+}
+
+// Row returns the i-th row of the matrix, it shares its values with the matrix
+func (matrix Matrix) Row(i int) []float64 {
+	end := (i + 1) * matrix.columns
+	return matrix.data[i*matrix.columns : end : end]
+}
+
+func (matrix Matrix) At(i, j int) float64 {
+	return matrix.data[i*matrix.columns+j]
+}
+
+func (matrix Matrix) Set(i, j int, x float64) {
+	matrix.data[i*matrix.columns+j] = x
+}
+
+// Slices returns a copy of the matrix as a slice of rows
+func (matrix Matrix) Slices() [][]float64 {
+	if matrix.rows == 0 {
+		return nil
+	}
+
+	rows := make([][]float64, matrix.rows)
+	for i := range rows {
+		rows[i] = append([]float64(nil), matrix.Row(i)...)
+	}
+
+	return rows
+}
+
+// resize gives new dimensions to the matrix, its buffer is reused when it is large enough so the
+// values have to be overwritten by the caller.
+func (matrix *Matrix) resize(rows, columns int) {
+	if cap(matrix.data) < rows*columns {
+		matrix.data = make([]float64, rows*columns)
+	}
+
+	matrix.rows, matrix.columns, matrix.data = rows, columns, matrix.data[:rows*columns]
+}
+
+// MarshalJSON writes the matrix as a slice of rows, like the models saved before it was flattened
+func (matrix Matrix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(matrix.Slices())
+}
+
+func (matrix *Matrix) UnmarshalJSON(content []byte) error {
+	var rows [][]float64
+	if err := json.Unmarshal(content, &rows); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if len(row) != len(rows[0]) {
+			return errors.New("the rows of the matrix don't have the same length")
+		}
+	}
+
+	*matrix = NewMatrix(rows)
+	return nil
+}
+
+// GobEncode writes the dimensions of the matrix followed by its values
+func (matrix Matrix) GobEncode() ([]byte, error) {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, [2]uint32{uint32(matrix.rows), uint32(matrix.columns)})
+	binary.Write(&buffer, binary.LittleEndian, matrix.data)
+
+	return buffer.Bytes(), nil
+}
+
+func (matrix *Matrix) GobDecode(content []byte) error {
+	var dimensions [2]uint32
+	reader := bytes.NewReader(content)
+	if err := binary.Read(reader, binary.LittleEndian, &dimensions); err != nil {
+		return err
+	}
+
+	rows, columns := int(dimensions[0]), int(dimensions[1])
+	if uint64(reader.Len()) != uint64(rows)*uint64(columns)*8 {
+		return errors.New("the size of the matrix doesn't match its dimensions")
+	}
+
+	*matrix = CreateMatrix(rows, columns)
+	return binary.Read(reader, binary.LittleEndian, matrix.data)
+}
+
+func CreateMatricesLike(matrices []Matrix) (resultMatrices []Matrix) {
+	for _, matrix := range matrices {
+		resultMatrices = append(resultMatrices, CreateMatrix(Rows(matrix), Columns(matrix)))
+	}
+
+	return
+}
+
+// RepeatRow creates a matrix made of the given row repeated on each line
+func RepeatRow(row []float64, rows int) (resultMatrix Matrix) {
+	resultMatrix = CreateMatrix(rows, len(row))
+
+	for i := 0; i < rows; i++ {
+		copy(resultMatrix.Row(i), row)
+	}
+
+	return
+}
+
+func SumRows(matrix Matrix) (resultMatrix Matrix) {
+	SumRowsInto(&resultMatrix, matrix)
+	return
+}
+
+// SumRowsInto writes the sum of the rows of matrix in the single row of result
+func SumRowsInto(result *Matrix, matrix Matrix) {
+	result.resize(1, Columns(matrix))
+	sum := result.Row(0)
+	clear(sum)
+
+	for i := 0; i < matrix.rows; i++ {
+		for j, x := range matrix.Row(i) {
+			sum[j] += x
+		}
+	}
+}
+
+func CopyMatrices(matrices []Matrix) (resultMatrices []Matrix) {
+	for _, matrix := range matrices {
+		resultMatrices = append(resultMatrices, CopyMatrix(matrix))
+	}
+
+	return
+}
+
+func CopyMatrix(matrix Matrix) (resultMatrix Matrix) {
+	resultMatrix = CreateMatrix(Rows(matrix), Columns(matrix))
+	copy(resultMatrix.data, matrix.data)
+
+	return
+}
+
+// SelectRows creates a matrix with a copy of the rows at the given indexes
+func SelectRows(matrix Matrix, indexes []int) (resultMatrix Matrix) {
+	selectRowsInto(&resultMatrix, matrix, indexes)
+	return
+}
+
+// selectRowsInto copies the rows at the given indexes in result, reusing its buffer
+func selectRowsInto(result *Matrix, matrix Matrix, indexes []int) {
+	result.resize(len(indexes), matrix.columns)
+
+	for i, index := range indexes {
+		copy(result.Row(i), matrix.Row(index))
+	}
+}
+
+func Rows(matrix Matrix) int {
+	return matrix.rows
+}
+
+func Columns(matrix Matrix) int {
+	return matrix.columns
+}
+
+func ApplyFunctionWithIndex(matrix Matrix, fn func(i, j int, x float64) float64) Matrix {
+	for i := 0; i < matrix.rows; i++ {
+		row := matrix.Row(i)
+		for j, x := range row {
+			row[j] = fn(i, j, x)
+		}
+	}
+
+	return matrix
+}
+
+func ApplyFunction(matrix Matrix, fn func(x float64) float64) Matrix {
+	ApplyFunctionInto(&matrix, matrix, fn)
+	return matrix
+}
+
+// ApplyFunctionInto writes fn applied to each value of matrix in result, result can be matrix
+func ApplyFunctionInto(result *Matrix, matrix Matrix, fn func(x float64) float64) {
+	result.resize(Rows(matrix), Columns(matrix))
+
+	for i, x := range matrix.data {
+		result.data[i] = fn(x)
+	}
+}
+
+func ApplyRate(matrix Matrix, rate float64) Matrix {
+	return ApplyFunction(matrix, func(x float64) float64 {
+		return rate * x
+	})
+}
+
+// DotProduct returns matrix·matrix2
+func DotProduct(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := DotProductInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// TransposedDotProduct returns the transpose of matrix multiplied by matrix2 without creating the
+// transpose
+func TransposedDotProduct(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := TransposedDotProductInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// DotProductTransposed returns matrix multiplied by the transpose of matrix2 without creating the
+// transpose
+func DotProductTransposed(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := DotProductTransposedInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// DotProductInto writes matrix·matrix2 in result, reusing its buffer. result can't be one of the
+// operands since they are read while it is written.
+func DotProductInto(result *Matrix, matrix, matrix2 Matrix) error {
+	if Columns(matrix) != Rows(matrix2) {
+		return shapeError("dot product", matrix, matrix2)
+	}
+
+	if err := prepareProduct(result, Rows(matrix), Columns(matrix2), matrix, matrix2); err != nil {
+		return err
+	}
+
+	dotProduct(*result, matrix, matrix2)
+	return nil
+}
+
+// TransposedDotProductInto writes the transpose of matrix multiplied by matrix2 in result
+func TransposedDotProductInto(result *Matrix, matrix, matrix2 Matrix) error {
+	if Rows(matrix) != Rows(matrix2) {
+		return shapeError("transposed dot product", matrix, matrix2)
+	}
+
+	if err := prepareProduct(result, Columns(matrix), Columns(matrix2), matrix, matrix2); err != nil {
+		return err
+	}
+
+	transposedDotProduct(*result, matrix, matrix2)
+	return nil
+}
+
+// DotProductTransposedInto writes matrix multiplied by the transpose of matrix2 in result
+func DotProductTransposedInto(result *Matrix, matrix, matrix2 Matrix) error {
+	if Columns(matrix) != Columns(matrix2) {
+		return shapeError("dot product with a transpose", matrix, matrix2)
+	}
+
+	if err := prepareProduct(result, Rows(matrix), Rows(matrix2), matrix, matrix2); err != nil {
+		return err
+	}
+
+	dotProductTransposed(*result, matrix, matrix2)
+	return nil
+}
+
+// prepareProduct resizes the result of a product and fills it with zeros
+func prepareProduct(result *Matrix, rows, columns int, operands ...Matrix) error {
+	for _, operand := range operands {
+		if sharesBuffer(*result, operand) {
+			return fmt.Errorf("%w: the result of a product can't be one of its operands", ErrMatrixAliasing)
+		}
+	}
+
+	result.resize(rows, columns)
+	clear(result.data)
+
+	return nil
+}
+
+// sharesBuffer reports whether the two matrices start with the same value in memory
+func sharesBuffer(matrix, matrix2 Matrix) bool {
+	return cap(matrix.data) > 0 && cap(matrix2.data) > 0 && &matrix.data[:1][0] == &matrix2.data[:1][0]
+}
+
+func shapeError(operation string, matrix, matrix2 Matrix) error {
+	return fmt.Errorf(
+		"%w: %s of a %dx%d and a %dx%d matrix",
+		ErrMatrixShape, operation, Rows(matrix), Columns(matrix), Rows(matrix2), Columns(matrix2),
+	)
+}
+
+// dotProduct adds matrix·matrix2 to result. The columns of matrix are walked by blocks so that the
+// rows of matrix2 they multiply stay in the cache while they are used by all the rows of matrix.
+func dotProduct(result, matrix, matrix2 Matrix) {
+	parallelRows(matrix.rows, matrix.columns*matrix2.columns, func(from, to int) {
+		for block := 0; block < matrix.columns; block += dotProductBlockSize {
+			end := min(block+dotProductBlockSize, matrix.columns)
+
+			for i := from; i < to; i++ {
+				row, resultRow := matrix.Row(i)[block:end], result.Row(i)
+				for k, x := range row {
+					for j, y := range matrix2.Row(block + k) {
+						resultRow[j] += x * y
+					}
+				}
+			}
+		}
+	})
+}
+
+// transposedDotProduct adds the transpose of matrix multiplied by matrix2 to result, the rows of
+// result are split between the goroutines.
+func transposedDotProduct(result, matrix, matrix2 Matrix) {
+	parallelRows(matrix.columns, matrix.rows*matrix2.columns, func(from, to int) {
+		for i := 0; i < matrix.rows; i++ {
+			row, row2 := matrix.Row(i)[from:to], matrix2.Row(i)
+			for k, x := range row {
+				resultRow := result.Row(from + k)
+				for j, y := range row2 {
+					resultRow[j] += x * y
+				}
+			}
+		}
+	})
+}
+
+// dotProductTransposed adds matrix multiplied by the transpose of matrix2 to result, each value is
+// the product of two contiguous rows.
+func dotProductTransposed(result, matrix, matrix2 Matrix) {
+	parallelRows(matrix.rows, matrix.columns*matrix2.rows, func(from, to int) {
+		for i := from; i < to; i++ {
+			row, resultRow := matrix.Row(i), result.Row(i)
+			for k := range resultRow {
+				var sum float64
+				for j, y := range matrix2.Row(k) {
+					sum += row[j] * y
+				}
+
+				resultRow[k] += sum
+			}
+		}
+	})
+}
+
+// parallelRows splits the rows between goroutines when the number of multiplications, the rows
+// times the cost of each row, is large enough to be worth it.
+func parallelRows(rows, cost int, fn func(from, to int)) {
+	workers := min(runtime.GOMAXPROCS(0), rows)
+	if workers <= 1 || rows*cost < parallelThreshold {
+		fn(0, rows)
+		return
+	}
+
+	var group sync.WaitGroup
+	step := (rows + workers - 1) / workers
+	for from := 0; from < rows; from += step {
+		group.Add(1)
+		go func(from, to int) {
+			defer group.Done()
+			fn(from, to)
+		}(from, min(from+step, rows))
+	}
+	group.Wait()
+}
+
+// Sum returns a new matrix with matrix+matrix2, see SumInto for the shapes
+func Sum(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := SumInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// Difference returns a new matrix with matrix-matrix2, see SumInto for the shapes
+func Difference(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := DifferenceInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// Multiplication returns a new matrix with the element-wise product of matrix and matrix2, see
+// SumInto for the shapes
+func Multiplication(matrix, matrix2 Matrix) (resultMatrix Matrix) {
+	if err := MultiplicationInto(&resultMatrix, matrix, matrix2); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// SumInto writes matrix+matrix2 in result. matrix2 has the shape of matrix or a single row which is
+// then broadcast to all the rows of matrix, like the biases. result can be matrix, or matrix2 when
+// it isn't broadcast.
+func SumInto(result *Matrix, matrix, matrix2 Matrix) error {
+	return elementWiseInto("sum", result, matrix, matrix2, func(x, y float64) float64 {
+		return x + y
+	})
+}
+
+// DifferenceInto writes matrix-matrix2 in result, the shapes follow the rules of SumInto
+func DifferenceInto(result *Matrix, matrix, matrix2 Matrix) error {
+	return elementWiseInto("difference", result, matrix, matrix2, func(x, y float64) float64 {
+		return x - y
+	})
+}
+
+// MultiplicationInto writes the element-wise product of matrix and matrix2 in result, the shapes
+// follow the rules of SumInto
+func MultiplicationInto(result *Matrix, matrix, matrix2 Matrix) error {
+	return elementWiseInto("multiplication", result, matrix, matrix2, func(x, y float64) float64 {
+		return x * y
+	})
+}
+
+func elementWiseInto(operation string, result *Matrix, matrix, matrix2 Matrix, fn func(x, y float64) float64) error {
+	broadcast := Rows(matrix2) == 1 && Rows(matrix) != 1
+	if Columns(matrix) != Columns(matrix2) || (Rows(matrix) != Rows(matrix2) && !broadcast) {
+		return shapeError(operation, matrix, matrix2)
+	}
+
+	// The broadcast row would be overwritten before it is read by the next rows
+	if broadcast && sharesBuffer(*result, matrix2) {
+		return fmt.Errorf("%w: the result of a %s can't be a broadcast row", ErrMatrixAliasing, operation)
+	}
+
+	result.resize(Rows(matrix), Columns(matrix))
+	for i := 0; i < matrix.rows; i++ {
+		row, resultRow := matrix.Row(i), result.Row(i)
+		row2 := matrix2.Row(0)
+		if !broadcast {
+			row2 = matrix2.Row(i)
+		}
+
+		for j, x := range row {
+			resultRow[j] = fn(x, row2[j])
+		}
+	}
+
+	return nil
+}
+
+func Transpose(matrix Matrix) (resultMatrix Matrix) {
+	if err := TransposeInto(&resultMatrix, matrix); err != nil {
+		panic(err)
+	}
+
+	return
+}
+
+// TransposeInto writes the transpose of matrix in result, which can't be matrix
+func TransposeInto(result *Matrix, matrix Matrix) error {
+	if sharesBuffer(*result, matrix) {
+		return fmt.Errorf("%w: a matrix can't be transposed in itself", ErrMatrixAliasing)
+	}
+
+	result.resize(Columns(matrix), Rows(matrix))
+	for i := 0; i < matrix.rows; i++ {
+		for j, x := range matrix.Row(i) {
+			result.data[j*result.columns+i] = x
+		}
+	}
+
+	return nil
+}
+
+func (err *ModelError) Error() string {
+	return fmt.Sprintf("model %s: %s", err.FileName, err.Err)
+}
+
+func (err *ModelError) Unwrap() error {
+	return err.Err
+}
+
+// LoadNetwork reads a model saved in the JSON or in the binary format
+func LoadNetwork(fileName string) (*Network, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, &ModelError{fileName, err}
+	}
+
+	neuralNetwork := &Network{}
+	switch {
+	case bytes.HasPrefix(content, []byte(modelMagic)):
+		err = decodeBinaryNetwork(content, neuralNetwork)
+	case bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")):
+		err = json.Unmarshal(content, neuralNetwork)
+	default:
+		err = ErrModelFormat
+	}
+
+	if err == nil {
+		err = neuralNetwork.check()
+	}
+
+	if err != nil {
+		return nil, &ModelError{fileName, err}
+	}
+
+	neuralNetwork.upgrade()
+
+	// The vocabulary of the models saved without it is rebuilt from the intents, it doesn't match
+	// when they have changed
+	lastWeights := neuralNetwork.Weights[len(neuralNetwork.Weights)-1]
+	if len(neuralNetwork.Words) != Rows(neuralNetwork.Weights[0]) || len(neuralNetwork.Classes) != Columns(lastWeights) {
+		return nil, &ModelError{
+			fileName,
+			fmt.Errorf("%w: the vocabulary doesn't match the weights, the model must be re-trained", ErrModelFormat),
+		}
+	}
+
+	return neuralNetwork, nil
+}
+
+// check verifies that the version of the model is supported and that its weights are complete
+func (network Network) check() error {
+	if network.Version > ModelFormatVersion {
+		return fmt.Errorf(
+			"%w %d, this version of Olivia reads models up to the version %d",
+			ErrModelVersion, network.Version, ModelFormatVersion,
+		)
+	}
+
+	if len(network.Weights) == 0 || len(network.Weights) != len(network.Biases) {
+		return fmt.Errorf("%w: the weights or the biases are missing", ErrModelFormat)
+	}
+
+	for i := range network.Weights {
+		if Rows(network.Biases[i]) == 0 || Columns(network.Biases[i]) != Columns(network.Weights[i]) {
+			return fmt.Errorf("%w: the biases of the layer %d don't match its weights", ErrModelFormat, i)
+		}
+
+		if i > 0 && Rows(network.Weights[i]) != Columns(network.Weights[i-1]) {
+			return fmt.Errorf("%w: the weights of the layer %d don't follow the previous layer", ErrModelFormat, i)
+		}
+	}
+
+	return nil
+}
+
+// encodeBinaryNetwork writes the magic, the header, the gob encoded network and a CRC-32 checksum
+// of everything before it.
+func encodeBinaryNetwork(network Network) ([]byte, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(network); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(modelMagic)
+	binary.Write(&buffer, binary.LittleEndian, uint16(network.Version))
+	writeBinaryString(&buffer, network.Locale)
+
+	// The models converted from the full format don't have a creation date
+	var createdAt int64
+	if !network.CreatedAt.IsZero() {
+		createdAt = network.CreatedAt.UnixNano()
+	}
+	binary.Write(&buffer, binary.LittleEndian, createdAt)
+	writeBinaryString(&buffer, network.DataHash)
+	binary.Write(&buffer, binary.LittleEndian, uint64(payload.Len()))
+	buffer.Write(payload.Bytes())
+	binary.Write(&buffer, binary.LittleEndian, crc32.ChecksumIEEE(buffer.Bytes()))
+
+	return buffer.Bytes(), nil
+}
+
+func decodeBinaryNetwork(content []byte, network *Network) (err error) {
+	// The errors of the reader and of gob mean that the layout isn't the expected one
+	defer func() {
+		if err != nil && !errors.Is(err, ErrModelVersion) && !errors.Is(err, ErrModelChecksum) {
+			err = fmt.Errorf("%w: %v", ErrModelFormat, err)
+		}
+	}()
+
+	reader := bytes.NewReader(content[len(modelMagic):])
+
+	// The version is checked first since the next versions can change the rest of the layout
+	var version uint16
+	if err := binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	if version == 0 || version > ModelFormatVersion {
+		return fmt.Errorf(
+			"%w %d, this version of Olivia reads models up to the version %d",
+			ErrModelVersion, version, ModelFormatVersion,
+		)
+	}
+	// The matrices were encoded as slices of rows before
+	if version < minimumBinaryModelVersion {
+		return fmt.Errorf(
+			"%w %d, convert the model again from its JSON file or re-train it",
+			ErrModelVersion, version,
+		)
+	}
+
+	if len(content) < len(modelMagic)+2+4 {
+		return io.ErrUnexpectedEOF
+	}
+	body := content[:len(content)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(content[len(body):]) {
+		return ErrModelChecksum
+	}
+
+	locale, err := readBinaryString(reader)
+	if err != nil {
+		return err
+	}
+
+	var createdAt int64
+	if err = binary.Read(reader, binary.LittleEndian, &createdAt); err != nil {
+		return err
+	}
+
+	dataHash, err := readBinaryString(reader)
+	if err != nil {
+		return err
+	}
+
+	var payloadLength uint64
+	if err = binary.Read(reader, binary.LittleEndian, &payloadLength); err != nil {
+		return err
+	}
+	if payloadLength > uint64(reader.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, payloadLength)
+	if _, err = io.ReadFull(reader, payload); err != nil {
+		return err
+	}
+	if err = gob.NewDecoder(bytes.NewReader(payload)).Decode(network); err != nil {
+		return err
+	}
+
+	// The header is authoritative
+	network.Version = int(version)
+	network.Locale = locale
+	network.CreatedAt = time.Time{}
+	if createdAt != 0 {
+		network.CreatedAt = time.Unix(0, createdAt).UTC()
+	}
+	network.DataHash = dataHash
+
+	return nil
+}
+
+func writeBinaryString(buffer *bytes.Buffer, value string) {
+	binary.Write(buffer, binary.LittleEndian, uint16(len(value)))
+	buffer.WriteString(value)
+}
+
+func readBinaryString(reader *bytes.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// upgrade converts a network saved in the full format, with the training matrices and a row of
+// biases per sample, to the compact one.
+func (network *Network) upgrade() {
+	// Only the first row of biases was used to predict
+	for i, biases := range network.Biases {
+		if Rows(biases) > 1 {
+			network.Biases[i] = SelectRows(biases, []int{0})
+		}
+	}
+
+	// The vocabulary wasn't saved, it is rebuilt with the tokenization the model was trained with
+	if len(network.Words) == 0 {
+		network.Words, network.Classes = legacyVocabulary(network.Locale)
+	}
+}
+
+// ConvertNetwork reads a model and saves it in the format given by the extension of output, the
+// models saved in the full format are converted to the compact one.
+func ConvertNetwork(input, output string) error {
+	neuralNetwork, err := LoadNetwork(input)
+	if err != nil {
+		return err
+	}
+
+	return neuralNetwork.Save(output)
+}
+
+// CreateNetwork creates a network whose weights are drawn from random
+func CreateNetwork(locale string, rate float64, random *rand.Rand, input, output Matrix, hiddenLayers ...HiddenLayer) Network {
+	// Create the layers arrays and add the input values
+	inputMatrix := input
+	layers := []Matrix{inputMatrix}
+	activations := make([]string, len(hiddenLayers))
+	// Generate the hidden layer
+	for i, hiddenLayer := range hiddenLayers {
+		layers = append(layers, CreateMatrix(Rows(input), hiddenLayer.Nodes))
+		activations[i] = hiddenLayer.Activation
+	}
+	// Add the output layer, it is computed in place so it mustn't share the expected values
+	layers = append(layers, CreateMatrix(Rows(output), Columns(output)))
+
+	// Generate the weights and biases
+	weightsNumber := len(layers) - 1
+	var weights []Matrix
+	var biases []Matrix
 
 	for i := 0; i < weightsNumber; i++ {
 		rows, columns := Columns(layers[i]), Columns(layers[i+1])
 
-		weights = append(weights, RandomMatrix(rows, columns))
-		biases = append(biases, RandomMatrix(Rows(layers[i]), columns))
+		// The output layer is initialized as a sigmoid
+		activation := ActivationFunctions[SigmoidActivation]
+		if i < len(hiddenLayers) {
+			activation = ActivationFunctions[hiddenLayers[i].Activation]
+		}
+
+		weights = append(weights, InitializeWeights(rows, columns, activation, random))
+		// A single row of biases is broadcast to all the samples
+		biases = append(biases, CreateMatrix(1, columns))
+	}
+
+	return Network{
+		Layers:      layers,
+		Weights:     weights,
+		Biases:      biases,
+		Output:      output,
+		Rate:        rate,
+		Locale:      locale,
+		Activations: activations,
+		random:      random,
+	}
+}
+
+// ParseHiddenLayers parses hidden layers written as "nodes:activation" and separated by commas,
+// for example "50:relu,20:tanh".
+func ParseHiddenLayers(description string) (hiddenLayers []HiddenLayer, err error) {
+	for _, layer := range strings.Split(description, ",") {
+		nodes, activation, _ := strings.Cut(strings.TrimSpace(layer), ":")
+		if activation == "" {
+			activation = SigmoidActivation
+		}
+
+		hiddenLayer := HiddenLayer{Activation: activation}
+		if hiddenLayer.Nodes, err = strconv.Atoi(nodes); err != nil || hiddenLayer.Nodes <= 0 {
+			return nil, fmt.Errorf("invalid number of nodes in the hidden layer %q", layer)
+		}
+
+		if _, exists := ActivationFunctions[activation]; !exists {
+			return nil, fmt.Errorf("unknown activation %q", activation)
+		}
+
+		hiddenLayers = append(hiddenLayers, hiddenLayer)
+	}
+
+	return hiddenLayers, nil
+}
+
+// GetActivation returns the activation of the given hidden layer, the networks saved before the
+// activations were stored use sigmoids.
+func (network Network) GetActivation(i int) Activation {
+	if i < len(network.Activations) {
+		if activation, exists := ActivationFunctions[network.Activations[i]]; exists {
+			return activation
+		}
+	}
+
+	return ActivationFunctions[SigmoidActivation]
+}
+
+// SetOutputActivation chooses the activation of the output layer and its loss, an empty loss picks
+// the one of the activation
+func (network *Network) SetOutputActivation(activation, loss string) error {
+	loss, err := OutputLoss(activation, loss)
+	if err != nil {
+		return err
+	}
+
+	network.OutputActivation, network.Loss = activation, loss
+	return nil
+}
+
+// OutputLoss returns the loss used with the output activation, the mean squared error for a sigmoid
+// and the cross-entropy for a softmax when loss is empty. The binary cross-entropy can be chosen for
+// a sigmoid, the derivatives of the other combinations aren't computed.
+func OutputLoss(activation, loss string) (string, error) {
+	if activation == SoftmaxActivation {
+		if loss != "" && loss != CrossEntropyLoss {
+			return "", fmt.Errorf("the loss of a softmax output must be %s, not %q", CrossEntropyLoss, loss)
+		}
+
+		return CrossEntropyLoss, nil
+	}
+
+	switch loss {
+	case "", MeanSquaredLoss:
+		return MeanSquaredLoss, nil
+	case BinaryCrossEntropyLoss:
+		return loss, nil
+	}
+
+	return "", fmt.Errorf("the loss of a sigmoid output must be %s or %s, not %q", MeanSquaredLoss, BinaryCrossEntropyLoss, loss)
+}
+
+// GetOutputActivation returns the activation of the output layer, the networks saved before it was
+// stored use a sigmoid.
+func (network Network) GetOutputActivation() string {
+	if network.OutputActivation == "" {
+		return SigmoidActivation
+	}
+
+	return network.OutputActivation
+}
+
+func (network Network) GetOptimizer() string {
+	if network.Optimizer == "" {
+		return SGDOptimizer
+	}
+
+	return network.Optimizer
+}
+
+func (network Network) GetLoss() string {
+	if network.Loss == "" {
+		return MeanSquaredLoss
+	}
+
+	return network.Loss
+}
+
+// Save writes the network in JSON if the file name ends with .json, in the binary format otherwise
+func (network Network) Save(fileName string) error {
+	// The training matrices aren't saved and the model gets the current version
+	network.Layers, network.Output = nil, Matrix{}
+	network.Version = ModelFormatVersion
+
+	var content []byte
+	var err error
+	if filepath.Ext(fileName) == ".json" {
+		content, err = json.Marshal(network)
+	} else {
+		content, err = encodeBinaryNetwork(network)
+	}
+
+	if err == nil {
+		err = os.WriteFile(fileName, content, 0644)
+	}
+
+	if err != nil {
+		return &ModelError{fileName, err}
+	}
+
+	return nil
+}
+
+// Activate computes the layer which follows the i-th layer
+func (network Network) Activate(i int, layer Matrix) Matrix {
+	var productMatrix Matrix
+	if err := network.ActivateInto(&productMatrix, i, layer); err != nil {
+		panic(err)
+	}
+
+	return productMatrix
+}
+
+// ActivateInto computes the layer which follows the i-th layer in result, reusing its buffer
+func (network Network) ActivateInto(result *Matrix, i int, layer Matrix) error {
+	if err := DotProductInto(result, layer, network.Weights[i]); err != nil {
+		return err
+	}
+	// The row of biases is broadcast to all the samples
+	if err := SumInto(result, *result, network.Biases[i]); err != nil {
+		return err
+	}
+
+	// Normalize the output layer into probabilities when it uses a softmax
+	if i == len(network.Weights)-1 && network.GetOutputActivation() == SoftmaxActivation {
+		Softmax(*result)
+	} else if i == len(network.Weights)-1 {
+		ApplyFunction(*result, Sigmoid)
+	} else {
+		ApplyFunction(*result, network.GetActivation(i).Function)
+	}
+
+	return nil
+}
+
+func (network *Network) FeedForward() {
+	network.feedForward(0)
+}
+
+// feedForward computes the layers, the units of the hidden layers are dropped with the given
+// probability and the kept ones are scaled so that their expected value doesn't change
+func (network *Network) feedForward(dropout float64) {
+	if dropout <= 0 {
+		network.dropoutMasks = nil
+	} else if len(network.dropoutMasks) != len(network.Layers) {
+		network.dropoutMasks = make([]Matrix, len(network.Layers))
+	}
+
+	for i := range network.Weights {
+		// Replace the output values
+		if err := network.ActivateInto(&network.Layers[i+1], i, network.Layers[i]); err != nil {
+			panic(err)
+		}
+
+		// The output layer is never dropped
+		if dropout > 0 && i < len(network.Weights)-1 {
+			network.dropOut(i+1, dropout)
+		}
+	}
+}
+
+func (network *Network) dropOut(l int, dropout float64) {
+	mask := &network.dropoutMasks[l]
+	mask.resize(Rows(network.Layers[l]), Columns(network.Layers[l]))
+
+	for k := range mask.data {
+		mask.data[k] = 0
+		if network.random.Float64() >= dropout {
+			mask.data[k] = 1 / (1 - dropout)
+		}
+	}
+
+	if err := MultiplicationInto(&network.Layers[l], network.Layers[l], *mask); err != nil {
+		panic(err)
+	}
+}
+
+// Forward computes the output layer for the given inputs without touching the layers of the
+// network, so it can be called concurrently
+func (network Network) Forward(inputs Matrix) Matrix {
+	layer := inputs
+	for i := range network.Weights {
+		layer = network.Activate(i, layer)
+	}
+
+	return layer
+}
+
+func (network Network) Predict(input []float64) []float64 {
+	return network.Forward(Matrix{rows: 1, columns: len(input), data: input}).Row(0)
+}
+
+func (network *Network) FeedBackward(optimizer Optimizer) {
+	// The derivatives are kept between the steps to reuse their matrices
+	if len(network.derivatives) != len(network.Weights) {
+		network.derivatives = make([]LayerDerivative, len(network.Weights))
+	}
+
+	err := network.CalculateFinalLayerDerivatives(&network.derivatives[0])
+
+	// Compute the derivatives of the hidden layers
+	for i := 0; err == nil && i < len(network.Layers)-2; i++ {
+		err = network.CalculateLayerDerivatives(i, network.derivatives)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	// Then adjust the weights and biases
+	network.ApplyAdjustments(network.derivatives, optimizer)
+}
+
+func (network *Network) ComputeError() float64 {
+	// Feed forward to compute the last layer's values
+	network.FeedForward()
+
+	return network.ComputeLoss(network.Layers[len(network.Layers)-1], network.Output)
+}
+
+// ComputeLoss returns the loss of the network between its predictions and the expected outputs
+func (network Network) ComputeLoss(predictions, outputs Matrix) float64 {
+	// The cross-entropy is averaged by sample
+	if network.GetLoss() == CrossEntropyLoss {
+		var sum float64
+		for i, expected := range outputs.data {
+			// Clamp the probability to avoid log(0)
+			sum -= expected * math.Log(math.Max(predictions.data[i], 1e-12))
+		}
+
+		return sum / float64(Rows(outputs))
+	}
+
+	// Each sigmoid output is a separate yes or no, both of its outcomes are counted
+	if network.GetLoss() == BinaryCrossEntropyLoss {
+		var sum float64
+		for i, expected := range outputs.data {
+			y := math.Min(math.Max(predictions.data[i], 1e-12), 1-1e-12)
+			sum -= expected*math.Log(y) + (1-expected)*math.Log(1-y)
+		}
+
+		return sum / float64(Rows(outputs))
+	}
+
+	errors := Difference(outputs, predictions)
+
+	// Make the sum of all the squared errors
+	var sum float64
+	for _, e := range errors.data {
+		sum += e * e
+	}
+
+	// Compute the average
+	return sum / float64(len(errors.data))
+}
+
+// ComputeAccuracy returns the part of the samples whose best prediction is the expected class
+func ComputeAccuracy(predictions, outputs Matrix) float64 {
+	var correct int
+	for i := 0; i < Rows(outputs); i++ {
+		if ArgMax(predictions.Row(i)) == ArgMax(outputs.Row(i)) {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(Rows(outputs))
+}
+
+func ArgMax(values []float64) (index int) {
+	for i, value := range values {
+		if value > values[index] {
+			index = i
+		}
+	}
+
+	return
+}
+
+// recordEpoch appends the metrics of the current epoch and returns its validation loss
+func (network *Network) recordEpoch() float64 {
+	predictions := network.Forward(network.Layers[0])
+	network.TrainingLoss = append(network.TrainingLoss, network.ComputeLoss(predictions, network.Output))
+	network.TrainingAccuracy = append(network.TrainingAccuracy, ComputeAccuracy(predictions, network.Output))
+
+	if Rows(network.ValidationInput) == 0 {
+		return 0
+	}
+
+	predictions = network.Forward(network.ValidationInput)
+	validationLoss := network.ComputeLoss(predictions, network.ValidationOutput)
+	network.ValidationLoss = append(network.ValidationLoss, validationLoss)
+	network.ValidationAccuracy = append(network.ValidationAccuracy, ComputeAccuracy(predictions, network.ValidationOutput))
+
+	return validationLoss
+}
+
+func (network *Network) Train(iterations int) {
+	// Initialize the start date
+	start := time.Now()
+
+	optimizer, err := NewOptimizer(network.Optimizer, network.Rate)
+	if err != nil {
+		panic(err)
+	}
+
+	if network.random == nil {
+		network.random = rand.New(rand.NewSource(network.Seed))
+	}
+
+	// Keep the whole training set aside to split it in batches
+	inputs, outputs := network.Layers[0], network.Output
+	order := make([]int, Rows(inputs))
+	for i := range order {
+		order[i] = i
+	}
+
+	batchSize := network.BatchSize
+	if batchSize <= 0 || batchSize > len(order) {
+		batchSize = len(order)
+	}
+	network.samples = len(order)
+
+	// Create the progress bar
+	bar := pb.New(iterations).Postfix(fmt.Sprintf(
+		" - %s %s %s",
+		color.FgBlue.Render("Training the"),
+		color.FgRed.Render(GetNameByTag(network.Locale)),
+		color.FgBlue.Render("neural network"),
+	))
+	bar.Format("(██░)")
+	bar.SetMaxWidth(60)
+	bar.ShowCounters = false
+	bar.Start()
+
+	// Keep the weights of the epoch with the lowest validation loss for the early stopping
+	bestLoss := math.Inf(1)
+	var bestWeights, bestBiases []Matrix
+	earlyStopping := network.Patience > 0 && Rows(network.ValidationInput) > 0
+	// The batches are copied in the same buffers at each step
+	var batchInputs, batchOutputs Matrix
+
+	// Train the network
+	for i := 0; i < iterations; i++ {
+		if network.Shuffle {
+			network.random.Shuffle(len(order), func(a, b int) {
+				order[a], order[b] = order[b], order[a]
+			})
+		}
+
+		for from := 0; from < len(order); from += batchSize {
+			batch := order[from:min(from+batchSize, len(order))]
+
+			selectRowsInto(&batchInputs, inputs, batch)
+			selectRowsInto(&batchOutputs, outputs, batch)
+
+			network.Layers[0], network.Output = batchInputs, batchOutputs
+			network.feedForward(network.Dropout)
+			network.FeedBackward(optimizer)
+		}
+
+		network.Layers[0], network.Output = inputs, outputs
+
+		// Append errors for dashboard data
+		if iterations < 20 || i%(iterations/20) == 0 {
+			network.Errors = append(
+				network.Errors,
+				// Round the error to two decimals
+				network.ComputeError(),
+			)
+		}
+
+		network.Epochs = i + 1
+		validationLoss := network.recordEpoch()
+
+		// Increment the progress bar
+		bar.Increment()
+
+		if !earlyStopping {
+			continue
+		}
+
+		if validationLoss < bestLoss {
+			bestLoss, network.BestEpoch = validationLoss, i+1
+			bestWeights, bestBiases = CopyMatrices(network.Weights), CopyMatrices(network.Biases)
+			continue
+		}
+
+		// Stop when the validation loss hasn't improved for a while
+		if i+1-network.BestEpoch >= network.Patience {
+			break
+		}
+	}
+
+	bar.Finish()
+
+	// Restore the best weights
+	if bestWeights != nil {
+		network.Weights, network.Biases = bestWeights, bestBiases
+	}
+	// Print the error
+	arrangedError := fmt.Sprintf("%.5f", network.ComputeError())
+
+	// Calculate elapsed date
+	elapsed := time.Since(start)
+	// Round the elapsed date at two decimals
+	network.Time = math.Floor(elapsed.Seconds()*100) / 100
+
+	fmt.Printf("The error rate is %s.\n", color.FgGreen.Render(arrangedError))
+}
+
+func GetCoverage(writer http.ResponseWriter, _ *http.Request) {
+	allowedHeaders := "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization,Olivia-Token"
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+	writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+	writer.Header().Set("Access-Control-Expose-Headers", "Authorization")
+
+	resourcesMutex.RLock()
+	defer resourcesMutex.RUnlock()
+
+	json.NewEncoder(writer).Encode(ComputeCoverage())
+}
+
+// ComputeCoverage compares the resources of each locale with the English ones, the messages and
+// the intents of the locales must be cached
+func ComputeCoverage() []LocaleCoverage {
+	// The requests compute the coverage at the same time under the read lock, nothing is shared
+	defaultMessages, defaultIntents, defaultModules :=
+		RetrieveCachedMessages("en"), GetIntents_l("en"), GetModulesf("en")
+
+	var coverage []LocaleCoverage
+
+	// Calculate coverage for each language
+	for _, locale := range Locales {
+		if locale.Tag == "en" {
+			continue
+		}
+
+		coverage = append(coverage, LocaleCoverage{
+			Tag:      locale.Tag,
+			Language: GetNameByTag(locale.Tag),
+			Coverage: Coverage{
+				Modules:      getModuleCoverage(locale.Tag, defaultModules),
+				Intents:      getIntentCoverage(locale.Tag, defaultIntents),
+				Messages:     getMessageCoverage(locale.Tag, defaultMessages),
+				Tables:       getTableCoverage(locale.Tag),
+				Countries:    getCountryCoverage(locale.Tag),
+				Placeholders: getPlaceholderMismatches(locale.Tag, defaultMessages, defaultIntents, defaultModules),
+				Patterns:     getPatternParity(locale.Tag, defaultIntents, defaultModules),
+			},
+		})
+	}
+
+	return coverage
+}
+
+// Print writes the coverage of the locale as a report, the pattern counts are only written when
+// they differ from the English ones
+func (coverage LocaleCoverage) Print(output io.Writer) {
+	fmt.Fprintf(
+		output, "\n%s %s (%s)\n\n",
+		color.FgBlue.Render("Coverage of the"),
+		color.FgRed.Render(coverage.Language),
+		coverage.Tag,
+	)
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "RESOURCE\tCOVERAGE\tNOT COVERED")
+	for _, resource := range []struct {
+		name    string
+		details CoverageDetails
+	}{
+		{"messages", coverage.Coverage.Messages},
+		{"intents", coverage.Coverage.Intents},
+		{"modules", coverage.Coverage.Modules},
+		{"tables", coverage.Coverage.Tables},
+		{"countries", coverage.Coverage.Countries},
+	} {
+		percentage := "N/A"
+		if resource.details.Coverage != nil {
+			percentage = fmt.Sprintf("%d%%", *resource.details.Coverage)
+		}
+
+		fmt.Fprintf(
+			writer, "%s\t%s\t%s\n",
+			resource.name, percentage, strings.Join(resource.details.NotCovered, ", "),
+		)
+	}
+	writer.Flush()
+
+	if len(coverage.Coverage.Placeholders) > 0 {
+		fmt.Fprintf(output, "\n%s\n", color.FgBlue.Render("Placeholders (expected → found):"))
+		for _, mismatch := range coverage.Coverage.Placeholders {
+			fmt.Fprintf(
+				output, "  %s %s: %q [%s] → [%s]\n",
+				mismatch.Resource, mismatch.Tag, mismatch.Text,
+				strings.Join(mismatch.Expected, " "), strings.Join(mismatch.Found, " "),
+			)
+		}
+	}
+
+	var header bool
+	for _, parity := range coverage.Coverage.Patterns {
+		if parity.Patterns == parity.DefaultPatterns {
+			continue
+		}
+
+		if !header {
+			fmt.Fprintf(output, "\n%s\n", color.FgBlue.Render("Patterns (locale / English):"))
+			header = true
+		}
+		fmt.Fprintf(output, "  %s %s: %d / %d\n", parity.Resource, parity.Tag, parity.Patterns, parity.DefaultPatterns)
+	}
+}
+
+func getMessageCoverage(locale string, defaultMessages []DataPacket) CoverageDetails {
+	var notCoveredMessages []string
+
+	// Iterate through the default messages which are the english ones to verify if a message isn't
+	// translated in the given locale.
+	for _, defaultMessage := range defaultMessages {
+		message := FindMessageByLabel(defaultMessage.Label, locale)
+
+		// Add the current module tag to the list of not-covered-modules
+		if message.Label != defaultMessage.Label {
+			notCoveredMessages = append(notCoveredMessages, defaultMessage.Label)
+		}
+	}
+
+	// Calculate the percentage of modules that aren't translated in the given locale
+	coverage := calculateCoverage(len(notCoveredMessages), len(defaultMessages))
+
+	return CoverageDetails{
+		NotCovered: notCoveredMessages,
+		Coverage:   coverage,
+	}
+}
+
+func getIntentCoverage(locale string, defaultIntents []Intent) CoverageDetails {
+	var notCoveredIntents []string
+
+	// Iterate through the default intents which are the english ones to verify if an intent isn't
+	// translated in the given locale.
+	for _, defaultIntent := range defaultIntents {
+		intent := GetIntentByTag(defaultIntent.Tag, locale)
+
+		// Add the current module tag to the list of not-covered-modules
+		if intent.Tag != defaultIntent.Tag {
+			notCoveredIntents = append(notCoveredIntents, defaultIntent.Tag)
+		}
+	}
+
+	// Calculate the percentage of intents that aren't translated in the given locale
+	coverage := calculateCoverage(len(notCoveredIntents), len(defaultIntents))
+
+	return CoverageDetails{
+		NotCovered: notCoveredIntents,
+		Coverage:   coverage,
+	}
+}
+
+func getModuleCoverage(locale string, defaultModules []Modulef) CoverageDetails {
+	var notCoveredModules []string
+
+	// Iterate through the default modules which are the english ones to verify if a module isn't
+	// translated in the given locale.
+	for _, defaultModule := range defaultModules {
+		module := GetModuleByTagf(defaultModule.Tag, locale)
+
+		// Add the current module tag to the list of not-covered-modules
+		if module.Tag != defaultModule.Tag {
+			notCoveredModules = append(notCoveredModules, defaultModule.Tag)
+		}
+	}
+
+	// Calculate the percentage of modules that aren't translated in the given locale
+	coverage := calculateCoverage(len(notCoveredModules), len(defaultModules))
+
+	return CoverageDetails{
+		NotCovered: notCoveredModules,
+		Coverage:   coverage,
+	}
+}
+
+// getTableCoverage compares the entries of the locale pack with the ones of the English pack
+func getTableCoverage(locale string) CoverageDetails {
+	defaultEntries := localePackEntries(localePacks["en"])
+	entries := localePackEntries(localePacks[locale])
+
+	var notCoveredEntries []string
+	for _, entry := range defaultEntries {
+		if !slices.Contains(entries, entry) {
+			notCoveredEntries = append(notCoveredEntries, entry)
+		}
+	}
+
+	return CoverageDetails{
+		NotCovered: notCoveredEntries,
+		Coverage:   calculateCoverage(len(notCoveredEntries), len(defaultEntries)),
+	}
+}
+
+// localePackEntries returns the names of the entries which are given by the pack, the genres and
+// the articles are named after the English ones
+func localePackEntries(pack LocalePack) (entries []string) {
+	add := func(name, value string) {
+		if value != "" {
+			entries = append(entries, name)
+		}
+	}
+
+	for i, day := range pack.Rules.DaysOfWeek {
+		add(fmt.Sprintf("rules.days_of_week.%d", i), day)
+	}
+	for i, month := range pack.Rules.Months {
+		add(fmt.Sprintf("rules.months.%d", i), month)
+	}
+	add("rules.today", pack.Rules.RuleToday)
+	add("rules.tomorrow", pack.Rules.RuleTomorrow)
+	add("rules.after_tomorrow", pack.Rules.RuleAfterTomorrow)
+	add("rules.day_of_week", pack.Rules.RuleDayOfWeek)
+	add("rules.next_day_of_week", pack.Rules.RuleNextDayOfWeek)
+	add("rules.natural_date", pack.Rules.RuleNaturalDate)
+	add("patterns.date", pack.Patterns.DateRegex)
+	add("patterns.time", pack.Patterns.TimeRegex)
+	add("math_decimals", pack.MathDecimals)
+	add("reason_keywords.that", pack.ReasonKeywords.That)
+	add("reason_keywords.to", pack.ReasonKeywords.To)
+	add("spotify_keywords.play", pack.SpotifyKeywords.Play)
+	add("spotify_keywords.from", pack.SpotifyKeywords.From)
+	add("spotify_keywords.on", pack.SpotifyKeywords.On)
+
+	englishGenres := localePacks["en"].MoviesGenres
+	for i, genre := range pack.MoviesGenres {
+		if i < len(englishGenres) {
+			add("movies_genres."+englishGenres[i], genre)
+		}
+	}
+	for country, article := range pack.CountriesArticles {
+		add("countries_articles."+country, article)
+	}
+
+	return
+}
+
+// getCountryCoverage returns the countries whose name isn't translated in the locale
+func getCountryCoverage(locale string) CoverageDetails {
+	var notCoveredCountries []string
+	for _, country := range countries {
+		if country.Name[locale] == "" {
+			notCoveredCountries = append(notCoveredCountries, country.Name["en"])
+		}
+	}
+
+	return CoverageDetails{
+		NotCovered: notCoveredCountries,
+		Coverage:   calculateCoverage(len(notCoveredCountries), len(countries)),
+	}
+}
+
+// Placeholders returns the verbs and the {slots} of the text in their order, the %% aren't
+// placeholders
+func Placeholders(text string) (placeholders []string) {
+	for _, placeholder := range placeholderRegex.FindAllString(text, -1) {
+		if placeholder != "%%" {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+
+	return
+}
+
+// getPlaceholderMismatches returns the translated responses and messages whose placeholders
+// aren't the ones of any of the English texts with the same tag
+func getPlaceholderMismatches(
+	locale string, defaultMessages []DataPacket, defaultIntents []Intent, defaultModules []Modulef,
+) (mismatches []PlaceholderMismatch) {
+	compare := func(resource, tag string, defaultTexts, texts []string) {
+		if len(defaultTexts) == 0 {
+			return
+		}
+
+		for _, text := range texts {
+			found := Placeholders(text)
+			matches := slices.ContainsFunc(defaultTexts, func(defaultText string) bool {
+				return slices.Equal(Placeholders(defaultText), found)
+			})
+
+			if !matches {
+				mismatches = append(mismatches, PlaceholderMismatch{
+					Resource: resource,
+					Tag:      tag,
+					Text:     text,
+					Expected: Placeholders(defaultTexts[0]),
+					Found:    found,
+				})
+			}
+		}
+	}
+
+	for _, defaultMessage := range defaultMessages {
+		compare("messages", defaultMessage.Label, defaultMessage.Content, FindMessageByLabel(defaultMessage.Label, locale).Content)
+	}
+	for _, defaultIntent := range defaultIntents {
+		compare("intents", defaultIntent.Tag, defaultIntent.Responses, GetIntentByTag(defaultIntent.Tag, locale).Responses)
+	}
+	for _, defaultModule := range defaultModules {
+		compare("modules", defaultModule.Tag, defaultModule.Responses, GetModuleByTagf(defaultModule.Tag, locale).Responses)
+	}
+
+	return
+}
+
+// getPatternParity returns the number of patterns of the intents and modules translated in the
+// locale and of the English ones
+func getPatternParity(locale string, defaultIntents []Intent, defaultModules []Modulef) (parities []PatternParity) {
+	for _, defaultIntent := range defaultIntents {
+		if intent := GetIntentByTag(defaultIntent.Tag, locale); intent.Tag != "" {
+			parities = append(parities, PatternParity{
+				Resource:        "intents",
+				Tag:             intent.Tag,
+				Patterns:        len(intent.Patterns),
+				DefaultPatterns: len(defaultIntent.Patterns),
+			})
+		}
+	}
+
+	for _, defaultModule := range defaultModules {
+		if module := GetModuleByTagf(defaultModule.Tag, locale); module.Tag != "" {
+			parities = append(parities, PatternParity{
+				Resource:        "modules",
+				Tag:             module.Tag,
+				Patterns:        len(module.Patterns),
+				DefaultPatterns: len(defaultModule.Patterns),
+			})
+		}
+	}
+
+	return
+}
+
+// calculateCoverage returns the percentage of the English resources which are translated, nil when
+// there is nothing to translate
+func calculateCoverage(notCoveredLength, defaultLength int) *int {
+	if defaultLength == 0 {
+		return nil
+	}
+
+	coverage := 100 * (defaultLength - notCoveredLength) / defaultLength
+	return &coverage
+}
+
+// TranslationUnits returns the English messages, patterns and responses with their translation in
+// the locale, the entries which only exist in the locale are left out
+func TranslationUnits(locale string) (units []TranslationUnit, err error) {
+	defaultMessages, err := ReadMessages("en")
+	if err != nil {
+		return nil, err
+	}
+	defaultIntents, err := ReadIntents("en")
+	if err != nil {
+		return nil, err
+	}
+
+	messages, intents, err := readTranslatedResources(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, defaultMessage := range defaultMessages {
+		units = appendTranslationUnits(
+			units, "messages/"+defaultMessage.Label,
+			defaultMessage.Content, findDataPacket(messages, defaultMessage.Label).Content,
+		)
+	}
+
+	for _, defaultIntent := range defaultIntents {
+		intent := findIntent(intents, defaultIntent.Tag)
+		units = appendTranslationUnits(units, "intents/"+defaultIntent.Tag+"/patterns", defaultIntent.Patterns, intent.Patterns)
+		units = appendTranslationUnits(units, "intents/"+defaultIntent.Tag+"/responses", defaultIntent.Responses, intent.Responses)
+	}
+
+	return
+}
+
+// readTranslatedResources reads the messages and the intents of the locale with the empty texts
+// which keep the positions of the units, the files which don't exist yet have no entries
+func readTranslatedResources(locale string) (messages []DataPacket, intents []Intent, err error) {
+	entries, err := readRawEntries(resourcePath("../res/locales/" + locale + "/messages.json"))
+	if err != nil {
+		return
+	}
+	messages = make([]DataPacket, len(entries))
+	for i, entry := range entries {
+		if err = json.Unmarshal(entry, &messages[i]); err != nil {
+			return nil, nil, fmt.Errorf("the messages of %q are invalid: %w", locale, err)
+		}
+	}
+
+	entries, err = readRawEntries(resourcePath("../res/locales/" + locale + "/intents.json"))
+	if err != nil {
+		return
+	}
+	intents = make([]Intent, len(entries))
+	for i, entry := range entries {
+		if err = json.Unmarshal(entry, &intents[i]); err != nil {
+			return nil, nil, fmt.Errorf("the intents of %q are invalid: %w", locale, err)
+		}
+	}
+
+	return messages, intents, nil
+}
+
+func appendTranslationUnits(units []TranslationUnit, prefix string, sources, targets []string) []TranslationUnit {
+	for i, source := range sources {
+		unit := TranslationUnit{Key: fmt.Sprintf("%s/%d", prefix, i), Source: source}
+		if i < len(targets) {
+			unit.Target = targets[i]
+		}
+
+		units = append(units, unit)
+	}
+
+	return units
+}
+
+func findDataPacket(messages []DataPacket, tag string) DataPacket {
+	for _, message := range messages {
+		if message.Label == tag {
+			return message
+		}
+	}
+
+	return DataPacket{}
+}
+
+func findIntent(intents []Intent, tag string) Intent {
+	for _, intent := range intents {
+		if intent.Tag == tag {
+			return intent
+		}
+	}
+
+	return Intent{}
+}
+
+// ExportTranslations returns the translation units of the locale in the PO or the XLIFF 2.0 format
+func ExportTranslations(locale, format string) ([]byte, error) {
+	units, err := TranslationUnits(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case POFormat:
+		return ExportPO(locale, units), nil
+	case XLIFFFormat:
+		return ExportXLIFF(locale, units)
+	}
+
+	return nil, fmt.Errorf("the translation format %q isn't supported, use %s or %s", format, POFormat, XLIFFFormat)
+}
+
+// ExportPO writes the units as a gettext catalog, their keys are the contexts of the entries since
+// the same English text can be translated differently
+func ExportPO(locale string, units []TranslationUnit) []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(
+		&buffer, "msgid \"\"\nmsgstr \"\"\n%s\n%s\n%s\n",
+		quotePO("Content-Type: text/plain; charset=UTF-8\n"),
+		quotePO("Language: "+locale+"\n"),
+		quotePO("X-Source-Language: en\n"),
+	)
+
+	for _, unit := range units {
+		fmt.Fprintf(
+			&buffer, "\nmsgctxt %s\nmsgid %s\nmsgstr %s\n",
+			quotePO(unit.Key), quotePO(unit.Source), quotePO(unit.Target),
+		)
+	}
+
+	return buffer.Bytes()
+}
+
+func quotePO(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + replacer.Replace(text) + `"`
+}
+
+func unquotePO(text string) (string, error) {
+	if len(text) < 2 || text[0] != '"' || text[len(text)-1] != '"' {
+		return "", fmt.Errorf("%s isn't a quoted string", text)
+	}
+
+	replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t", `\r`, "\r")
+	return replacer.Replace(text[1 : len(text)-1]), nil
+}
+
+// ParsePO reads the units of a gettext catalog and the language of its header, the fuzzy entries
+// aren't translated
+func ParsePO(content []byte) (locale string, units []TranslationUnit, err error) {
+	var unit TranslationUnit
+	var field *string
+	var fuzzy, complete bool
+
+	flush := func() {
+		if fuzzy {
+			unit.Target = ""
+		}
+
+		// The entry without context and source is the header
+		if unit.Key == "" && unit.Source == "" {
+			for _, line := range strings.Split(unit.Target, "\n") {
+				if language, found := strings.CutPrefix(line, "Language:"); found {
+					locale = strings.TrimSpace(language)
+				}
+			}
+		} else {
+			units = append(units, unit)
+		}
+
+		unit, field, fuzzy, complete = TranslationUnit{}, nil, false, false
+	}
+
+	for number, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+
+		// The comments and the keywords after a msgstr start the next entry
+		if complete && (strings.HasPrefix(line, "#") || strings.HasPrefix(line, "msg")) {
+			flush()
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#,"):
+			fuzzy = fuzzy || strings.Contains(line, "fuzzy")
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			value = line
+		case keyword == "msgctxt":
+			field = &unit.Key
+		case keyword == "msgid":
+			field = &unit.Source
+		case keyword == "msgstr":
+			field, complete = &unit.Target, true
+		default:
+			return "", nil, fmt.Errorf("line %d: %q isn't supported", number+1, keyword)
+		}
+
+		if field == nil {
+			return "", nil, fmt.Errorf("line %d: a string must follow a keyword", number+1)
+		}
+
+		text, err := unquotePO(strings.TrimSpace(value))
+		if err != nil {
+			return "", nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		*field += text
+	}
+
+	if complete {
+		flush()
+	}
+
+	return
+}
+
+// ExportXLIFF writes the units as a XLIFF 2.0 document with a file for the messages and one for
+// the intents
+func ExportXLIFF(locale string, units []TranslationUnit) ([]byte, error) {
+	document := xliffDocument{Version: "2.0", Source: "en", Target: locale}
+
+	for _, unit := range units {
+		resource, _, _ := strings.Cut(unit.Key, "/")
+		if len(document.Files) == 0 || document.Files[len(document.Files)-1].ID != resource {
+			document.Files = append(document.Files, xliffFile{ID: resource})
+		}
+
+		file := &document.Files[len(document.Files)-1]
+		segment := xliffSegment{State: "initial", Source: unit.Source}
+		if unit.Target != "" {
+			target := unit.Target
+			segment.State, segment.Target = "translated", &target
+		}
+
+		file.Units = append(file.Units, xliffUnit{
+			ID:      strconv.Itoa(len(file.Units) + 1),
+			Name:    unit.Key,
+			Segment: segment,
+		})
+	}
+
+	content, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// ParseXLIFF reads the units of a XLIFF 2.0 document and its target language
+func ParseXLIFF(content []byte) (locale string, units []TranslationUnit, err error) {
+	var document xliffDocument
+	if err = xml.Unmarshal(content, &document); err != nil {
+		return "", nil, err
+	}
+
+	if !strings.HasPrefix(document.Version, "2.") {
+		return "", nil, fmt.Errorf("the XLIFF version %q isn't supported, only the 2.0 one is", document.Version)
+	}
+
+	for _, file := range document.Files {
+		for _, fileUnit := range file.Units {
+			unit := TranslationUnit{Key: fileUnit.Name, Source: fileUnit.Segment.Source}
+			if fileUnit.Segment.Target != nil {
+				unit.Target = *fileUnit.Segment.Target
+			}
+
+			units = append(units, unit)
+		}
+	}
+
+	return document.Target, units, nil
+}
+
+// ImportTranslations merges the translated units into the messages and the intents of the locale.
+// The translations of English texts which changed since the export are left out, and the entries
+// which aren't changed keep their bytes in the files.
+func ImportTranslations(locale, format string, content []byte) (report TranslationImport, err error) {
+	report.Locale = locale
+
+	var fileLocale string
+	var imported []TranslationUnit
+	switch format {
+	case POFormat:
+		fileLocale, imported, err = ParsePO(content)
+	case XLIFFFormat:
+		fileLocale, imported, err = ParseXLIFF(content)
+	default:
+		err = fmt.Errorf("the translation format %q isn't supported, use %s or %s", format, POFormat, XLIFFFormat)
+	}
+	if err != nil {
+		return
+	}
+
+	if fileLocale != "" && fileLocale != locale {
+		return report, fmt.Errorf("the translations are in %q instead of %q", fileLocale, locale)
+	}
+
+	units, err := TranslationUnits(locale)
+	if err != nil {
+		return
+	}
+
+	importedUnits := make(map[string]TranslationUnit, len(imported))
+	for _, unit := range imported {
+		importedUnits[unit.Key] = unit
+	}
+
+	translations := map[string]string{}
+	for _, unit := range units {
+		importedUnit, exists := importedUnits[unit.Key]
+		delete(importedUnits, unit.Key)
+
+		switch {
+		case !exists || importedUnit.Target == "":
+		case importedUnit.Source != unit.Source:
+			report.Stale = append(report.Stale, unit.Key)
+		default:
+			translations[unit.Key] = importedUnit.Target
+			if importedUnit.Target != unit.Target {
+				report.Updated = append(report.Updated, unit.Key)
+			}
+		}
+
+		if translations[unit.Key] == "" && unit.Target == "" {
+			report.Missing = append(report.Missing, unit.Key)
+		}
+	}
+
+	// The units left don't exist in the English resources anymore
+	stale := slices.Sorted(maps.Keys(importedUnits))
+	report.Stale = append(report.Stale, stale...)
+
+	if len(report.Updated) == 0 {
+		return
+	}
+
+	if err = mergeTranslatedMessages(locale, translations); err != nil {
+		return
+	}
+	err = mergeTranslatedIntents(locale, translations)
+
+	return
+}
+
+// mergeTranslatedList returns the list of the locale with the translations of the English one at
+// the same index, the texts which aren't translated are left empty so that the next ones keep the
+// index of their key. The texts of the locale after the English ones are kept.
+func mergeTranslatedList(prefix string, sources, targets []string, translations map[string]string) (merged []string) {
+	merged = make([]string, max(len(sources), len(targets)))
+	copy(merged, targets)
+
+	for i := range sources {
+		if translation := translations[fmt.Sprintf("%s/%d", prefix, i)]; translation != "" {
+			merged[i] = translation
+		}
+	}
+
+	// The empty texts at the end don't keep any position
+	for len(merged) > 0 && merged[len(merged)-1] == "" {
+		merged = merged[:len(merged)-1]
+	}
+
+	return
+}
+
+// isEmptyText reports whether the text of a list is an empty slot, see mergeTranslatedList
+func isEmptyText(text string) bool {
+	return text == ""
+}
+
+func mergeTranslatedMessages(locale string, translations map[string]string) error {
+	defaultMessages, err := ReadMessages("en")
+	if err != nil {
+		return err
 	}
 
-	return Network{
-		Layers:  layers,
-		Weights: weights,
-		Biases:  biases,
-		Output:  output,
-		Rate:    rate,
-		Locale:  locale,
+	path := resourcePath("../res/locales/" + locale + "/messages.json")
+	entries, err := readRawEntries(path)
+	if err != nil {
+		return err
+	}
+
+	positions := map[string]int{}
+	messages := make([]DataPacket, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal(entry, &messages[i]); err != nil {
+			return err
+		}
+		positions[messages[i].Label] = i
+	}
+
+	changed := false
+	for _, defaultMessage := range defaultMessages {
+		position, exists := positions[defaultMessage.Label]
+
+		var message DataPacket
+		if exists {
+			message = messages[position]
+		}
+
+		merged := mergeTranslatedList("messages/"+defaultMessage.Label, defaultMessage.Content, message.Content, translations)
+		if slices.Equal(merged, message.Content) {
+			continue
+		}
+
+		message.Label, message.Content = defaultMessage.Label, merged
+		entry, err := encodeRawEntry(message)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			entries[position] = entry
+		} else {
+			entries = append(entries, entry)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
 	}
+
+	return writeRawEntries(path, entries)
 }
 
-func (network Network) Save(fileName string) {
-	outF, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR, 0777)
+func mergeTranslatedIntents(locale string, translations map[string]string) error {
+	defaultIntents, err := ReadIntents("en")
 	if err != nil {
-		panic("Failed to save the network to " + fileName + ".")
+		return err
 	}
-	defer outF.Close()
 
-	encoder := json.NewEncoder(outF)
-	err = encoder.Encode(network)
+	path := resourcePath("../res/locales/" + locale + "/intents.json")
+	entries, err := readRawEntries(path)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	positions := map[string]int{}
+	_intents := make([]Intent, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal(entry, &_intents[i]); err != nil {
+			return err
+		}
+		positions[_intents[i].Tag] = i
+	}
+
+	changed := false
+	for _, defaultIntent := range defaultIntents {
+		position, exists := positions[defaultIntent.Tag]
+
+		// A new intent keeps the context and the slots of the English one
+		intent := defaultIntent
+		intent.Patterns, intent.Responses = nil, nil
+		if exists {
+			intent = _intents[position]
+		}
+
+		prefix := "intents/" + defaultIntent.Tag
+		patterns := mergeTranslatedList(prefix+"/patterns", defaultIntent.Patterns, intent.Patterns, translations)
+		responses := mergeTranslatedList(prefix+"/responses", defaultIntent.Responses, intent.Responses, translations)
+		if slices.Equal(patterns, intent.Patterns) && slices.Equal(responses, intent.Responses) {
+			continue
+		}
+
+		// An intent can't be added without its patterns and its responses
+		if !exists && (len(patterns) == 0 || len(responses) == 0) {
+			continue
+		}
+
+		intent.Patterns, intent.Responses = patterns, responses
+		entry, err := encodeRawEntry(intent)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			entries[position] = entry
+		} else {
+			entries = append(entries, entry)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
 	}
+
+	return writeRawEntries(path, entries)
 }
 
-func (network *Network) FeedForward() {
-	for i := 0; i < len(network.Layers)-1; i++ {
-		layer, weights, biases := network.Layers[i], network.Weights[i], network.Biases[i]
+// readRawEntries reads the entries of a JSON array without decoding them so that they can be
+// written back with the same bytes, a file which doesn't exist has no entries
+func readRawEntries(path string) (entries []json.RawMessage, err error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
 
-		productMatrix := DotProduct(layer, weights)
-		Sum(productMatrix, biases)
-		ApplyFunction(productMatrix, Sigmoid)
+	err = json.Unmarshal(content, &entries)
+	return
+}
 
-		// Replace the output values
-		network.Layers[i+1] = productMatrix
+// encodeRawEntry encodes an entry at the indentation of the entries of the locale files
+func encodeRawEntry(entry any) (json.RawMessage, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("  ", "  ")
+
+	if err := encoder.Encode(entry); err != nil {
+		return nil, err
 	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
 }
 
-func (network *Network) Predict(input []float64) []float64 {
-	network.Layers[0] = Matrix{input}
-	network.FeedForward()
-	return network.Layers[len(network.Layers)-1][0]
+func writeRawEntries(path string, entries []json.RawMessage) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("[\n")
+	for i, entry := range entries {
+		buffer.WriteString("  ")
+		buffer.Write(entry)
+		if i < len(entries)-1 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("]\n")
+
+	return os.WriteFile(path, buffer.Bytes(), 0644)
 }
 
-func (network *Network) FeedBackward() {
-	var derivatives []LayerDerivative
-	derivatives = append(derivatives, network.CalculateFinalLayerDerivatives())
+func (sentence *Sentence) arrange() {
+	// Remove the inverted marks which open the Spanish questions and exclamations
+	sentence.Content = openingPunctuationRegex.ReplaceAllString(sentence.Content, "")
 
-	// Compute the derivatives of the hidden layers
-	for i := 0; i < len(network.Layers)-2; i++ {
-		derivatives = append(derivatives, network.CalculateLayerDerivatives(i, derivatives))
+	// Remove punctuation after letters
+	sentence.Content = punctuationAfterLetterRegex.ReplaceAllStringFunc(sentence.Content, func(s string) string {
+		return punctuationRegex.ReplaceAllString(s, "")
+	})
+
+	sentence.Content = strings.ReplaceAll(sentence.Content, "-", " ")
+	sentence.Content = strings.TrimSpace(sentence.Content)
+}
+
+// CompileNLPContext reads the intents and builds the pipeline of the locale, words and classes are
+// the vocabulary and the intents of its model.
+func CompileNLPContext(locale string, words, classes []string) *NLPContext {
+	return compileNLPContext(locale, words, classes, SerializeIntents(locale))
+}
+
+// compileNLPContext compiles the context with the given intents rather than reading them
+func compileNLPContext(locale string, words, classes []string, _intents []Intent) *NLPContext {
+	context := &NLPContext{
+		Locale:      locale,
+		Words:       words,
+		WordIndexes: make(map[string]int, len(words)),
+		Classes:     classes,
+		Pipeline:    NewPipeline(locale),
+		// Append the modules intents to the intents from ../res/locales/<locale>/intents.json, the
+		// ones of the chain give the responses of the tags which the locale doesn't translate
+		Intents: append(chainIntents(locale, _intents), modulesIntents(GetChainModulesf(locale))...),
 	}
 
-	// Then adjust the weights and biases
-	network.ApplyAdjustments(derivatives)
+	for i, word := range words {
+		context.WordIndexes[word] = i
+	}
+
+	// The pipeline registered for the locale may bring its own corrector
+	if SpellingCorrection && context.Pipeline.Corrector == nil && GetSpellingBudget(locale) > 0 {
+		context.Pipeline.Corrector = context.newSpellingCorrector()
+	}
+
+	return context
 }
 
-func (network *Network) ComputeError() float64 {
-	// Feed forward to compute the last layer's values
-	network.FeedForward()
-	lastLayer := network.Layers[len(network.Layers)-1]
-	errors := Difference(network.Output, lastLayer)
+// newSpellingCorrector creates the corrector of the context from the words of its intents, the
+// names of the countries, the movies genres and ../res/datasets/names.txt
+func (context *NLPContext) newSpellingCorrector() *SpellingCorrector {
+	tokenize := func(content string) []string {
+		pipeline := context.Pipeline
+		pipeline.StopWords, pipeline.Stemmer = nil, nil
 
-	// Make the sum of all the errors
-	var i int
-	var sum float64
-	for _, a := range errors {
-		for _, e := range a {
-			sum += e
-			i++
+		return pipeline.Process(content)
+	}
+
+	// The stopwords are known even if the intents don't use them
+	var words []string
+	if stopWords, ok := context.Pipeline.StopWords.(StopWordSet); ok {
+		for word := range stopWords {
+			words = append(words, word)
 		}
 	}
 
-	// Compute the average
-	return sum / float64(i)
+	for _, intent := range context.Intents {
+		for _, pattern := range intent.Patterns {
+			sentence := Sentence{Locale: context.Locale, Content: pattern}
+			sentence.arrange()
+
+			words = append(words, tokenize(sentence.Content)...)
+		}
+	}
+
+	var gazetteer []string
+	for _, country := range countries {
+		gazetteer = append(gazetteer, tokenize(country.Name[context.Locale])...)
+	}
+	for _, genre := range MoviesGenres[context.Locale] {
+		gazetteer = append(gazetteer, tokenize(genre)...)
+	}
+
+	corrector := NewSpellingCorrector(GetSpellingBudget(context.Locale), append(words, gazetteer...))
+	corrector.names = namesTree()
+
+	return corrector
 }
 
-func (network *Network) Train(iterations int) {
-	// Initialize the start date
-	start := time.Now()
+// RegisterNLPContext replaces the context of its locale, the requests which already use the
+// previous one finish with it
+func RegisterNLPContext(context *NLPContext) {
+	nlpContexts.Store(context.Locale, context)
+}
 
-	// Create the progress bar
-	bar := pb.New(iterations).Postfix(fmt.Sprintf(
-		" - %s %s %s",
-		color.FgBlue.Render("Training the"),
-		color.FgRed.Render(GetNameByTag(network.Locale)),
-		color.FgBlue.Render("neural network"),
-	))
-	bar.Format("(██░)")
-	bar.SetMaxWidth(60)
-	bar.ShowCounters = false
-	bar.Start()
+// GetNLPContext returns the context of the locale, a context without vocabulary is compiled the
+// first time when the locale's model isn't loaded yet
+func GetNLPContext(locale string) *NLPContext {
+	if context, exists := nlpContexts.Load(locale); exists {
+		return context.(*NLPContext)
+	}
 
-	// Train the network
-	for i := 0; i < iterations; i++ {
-		network.FeedForward()
-		network.FeedBackward()
+	context, _ := nlpContexts.LoadOrStore(locale, CompileNLPContext(locale, nil, nil))
+	return context.(*NLPContext)
+}
 
-		// Append errors for dashboard data
-		if i%(iterations/20) == 0 {
-			network.Errors = append(
-				network.Errors,
-				// Round the error to two decimals
-				network.ComputeError(),
-			)
+// Context returns the context compiled for the vocabulary of the network when it was loaded or
+// trained, see SetContext
+func (network Network) Context() (*NLPContext, error) {
+	if network.context == nil {
+		return nil, fmt.Errorf("%w: the network of the locale %q", ErrNoNLPContext, network.Locale)
+	}
+
+	return network.context, nil
+}
+
+// SetContext compiles the context of the network's vocabulary with the intents and stores it in
+// the network, it is shared by the copies of the network made afterwards
+func (network *Network) SetContext(_intents []Intent) *NLPContext {
+	network.context = compileNLPContext(network.Locale, network.Words, network.Classes, _intents)
+	return network.context
+}
+
+// RegisterPipeline replaces the default pipeline of the locale by the one built by factory, it is
+// used by the locales which need their own stages
+func RegisterPipeline(locale string, factory func(locale string) Pipeline) {
+	pipelineFactories[locale] = factory
+}
+
+// NewPipeline builds the pipeline registered for the locale, or the default one
+func NewPipeline(locale string) Pipeline {
+	if factory, exists := pipelineFactories[locale]; exists {
+		return factory(locale)
+	}
+
+	return DefaultPipeline(locale)
+}
+
+// DefaultPipeline normalizes the sentences with the case rules of the locale's language, splits
+// them on the characters which aren't letters or digits and between the scripts, removes the
+// stopwords of ../res/locales/<locale>/stopwords.txt and stems the words with the snowball stemmer
+// of the locale's language.
+func DefaultPipeline(locale string) Pipeline {
+	pipeline := Pipeline{
+		Normalizer: UnicodeNormalizer{
+			Language:    language.Make(locale),
+			FoldAccents: FoldAccents[locale],
+		},
+		Tokenizer: UnicodeTokenizer{Segmenter: ReadDictionarySegmenter(locale)},
+		StopWords: ReadStopWords(locale),
+	}
+
+	// Set default language to english
+	language := GetNameByTag(locale)
+	if language == "" {
+		language = "english"
+	}
+
+	stemmer, err := GetSnowballStemmer(language)
+	if err != nil {
+		fmt.Println("Stemmer error", err)
+		return pipeline
+	}
+	pipeline.Stemmer = stemmer
+
+	return pipeline
+}
+
+// LegacyPipeline reproduces the tokenization of the models saved in the full format: the sentences
+// are split on the spaces, the stopwords are removed when a line of stopwords.txt contains them and
+// the words are stemmed in English whatever the locale.
+func LegacyPipeline(locale string) Pipeline {
+	pipeline := Pipeline{
+		Normalizer: legacyNormalizer{},
+		StopWords:  legacyStopWords(readStopWordLines(locale)),
+	}
+
+	stemmer, err := GetSnowballStemmer("english")
+	if err != nil {
+		fmt.Println("Stemmer error", err)
+		return pipeline
+	}
+	pipeline.Stemmer = stemmer
+
+	return pipeline
+}
+
+// legacyVocabulary rebuilds the words and the classes of a model saved in the full format from the
+// intents and the modules of its locale.
+func legacyVocabulary(locale string) (words, classes []string) {
+	pipeline := LegacyPipeline(locale)
+
+	for _, intent := range TrainedIntents(locale) {
+		for _, pattern := range intent.Patterns {
+			for _, word := range pipeline.Process(pattern) {
+				if !SliceIncludes(words, word) {
+					words = append(words, word)
+				}
+			}
 		}
 
-		// Increment the progress bar
-		bar.Increment()
+		classes = append(classes, intent.Tag)
 	}
 
-	bar.Finish()
-	// Print the error
-	arrangedError := fmt.Sprintf("%.5f", network.ComputeError())
+	sort.Strings(words)
+	sort.Strings(classes)
 
-	// Calculate elapsed date
-	elapsed := time.Since(start)
-	// Round the elapsed date at two decimals
-	network.Time = math.Floor(elapsed.Seconds()*100) / 100
+	return words, classes
+}
 
-	fmt.Printf("The error rate is %s.\n", color.FgGreen.Render(arrangedError))
+// Normalize removes the punctuation after the letters and the hyphens and lowers the case
+func (legacyNormalizer) Normalize(content string) string {
+	content = legacyPunctuationAfterLetterRegex.ReplaceAllStringFunc(content, func(s string) string {
+		return legacyPunctuationRegex.ReplaceAllString(s, "")
+	})
+
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(content, "-", " ")))
 }
 
-func GetCoverage(writer http.ResponseWriter, _ *http.Request) {
-	allowedHeaders := "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization,Olivia-Token"
-	writer.Header().Set("Access-Control-Allow-Origin", "*")
-	writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
-	writer.Header().Set("Access-Control-Expose-Headers", "Authorization")
+// Filter removes the tokens which are contained by a line of the stopwords
+func (stopWords legacyStopWords) Filter(tokens []string) []string {
+	// Don't remove stopwords for small sentences like “How are you” because it will remove all the words
+	if len(tokens) <= 4 {
+		return tokens
+	}
 
-	defaultMessages, defaultIntents, defaultModules =
-		RetrieveCachedMessages("en"), GetIntents_l("en"), GetModulesf("en")
+	var filteredTokens []string
+	for _, token := range tokens {
+		if !slices.ContainsFunc(stopWords, func(stopWord string) bool {
+			return strings.Contains(stopWord, token)
+		}) {
+			filteredTokens = append(filteredTokens, token)
+		}
+	}
 
-	var coverage []LocaleCoverage
+	return filteredTokens
+}
 
-	// Calculate coverage for each language
-	for _, locale := range Locales {
-		if locale.Tag == "en" {
-			continue
+// Process runs the stages of the pipeline on the sentence, the stages which aren't set are skipped
+func (pipeline Pipeline) Process(content string) []string {
+	tokens, _ := pipeline.Analyze(content)
+	return tokens
+}
+
+// Analyze runs the stages of the pipeline on the sentence and returns the corrections of the
+// misspelled words with its tokens
+func (pipeline Pipeline) Analyze(content string) (tokens []string, corrections []Correction) {
+	if pipeline.Normalizer != nil {
+		content = pipeline.Normalizer.Normalize(content)
+	}
+
+	tokens = strings.Fields(content)
+	if pipeline.Tokenizer != nil {
+		tokens = pipeline.Tokenizer.Tokenize(content)
+	}
+
+	if pipeline.Corrector != nil {
+		tokens, corrections = pipeline.Corrector.Correct(tokens)
+	}
+
+	if pipeline.StopWords != nil {
+		tokens = pipeline.StopWords.Filter(tokens)
+	}
+
+	if pipeline.Stemmer != nil {
+		for i, token := range tokens {
+			tokens[i] = pipeline.Stemmer.Stem(token)
 		}
+	}
 
-		coverage = append(coverage, LocaleCoverage{
-			Tag:      locale.Tag,
-			Language: GetNameByTag(locale.Tag),
-			Coverage: Coverage{
-				Modules:  getModuleCoverage(locale.Tag),
-				Intents:  getIntentCoverage(locale.Tag),
-				Messages: getMessageCoverage(locale.Tag),
-			},
-		})
+	return tokens, corrections
+}
+
+// Normalize composes the characters (NFC), folds their case and removes the accents when
+// FoldAccents is set
+func (normalizer UnicodeNormalizer) Normalize(content string) string {
+	content = normalizer.caser().String(norm.NFC.String(content))
+
+	if normalizer.FoldAccents {
+		content, _, _ = transform.String(
+			transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC),
+			content,
+		)
 	}
 
-	json.NewEncoder(writer).Encode(coverage)
+	// The stemmers only know the typewriter apostrophe
+	return strings.ReplaceAll(content, "’", "'")
 }
 
-func getMessageCoverage(locale string) CoverageDetails {
-	var notCoveredMessages []string
+// caser returns a new caser each time since they can't be shared between goroutines
+func (normalizer UnicodeNormalizer) caser() cases.Caser {
+	// The folding loses the dotted and dotless i of Turkish and Azerbaijani
+	if base, _ := normalizer.Language.Base(); base.String() == "tr" || base.String() == "az" {
+		return cases.Lower(normalizer.Language)
+	}
 
-	// Iterate through the default messages which are the english ones to verify if a message isn't
-	// translated in the given locale.
-	for _, defaultMessage := range defaultMessages {
-		message := FindMessageByLabel(defaultMessage.Label, locale)
+	return cases.Fold()
+}
 
-		// Add the current module tag to the list of not-covered-modules
-		if message.Label != defaultMessage.Label {
-			notCoveredMessages = append(notCoveredMessages, defaultMessage.Label)
+// Tokenize splits the content on the characters which aren't letters, marks or digits and where
+// the script changes to or from Chinese and Japanese. Their runs are split by the segmenter, or in
+// single characters without one. The apostrophes and the middle dots between two letters are kept
+// like in "what's" and in the Catalan "col·legi".
+func (tokenizer UnicodeTokenizer) Tokenize(content string) (tokens []string) {
+	runes := []rune(content)
+
+	start := -1
+	addToken := func(end int) {
+		token := string(runes[start:end])
+		if !IsCJK(runes[start]) {
+			tokens = append(tokens, token)
+			return
+		}
+
+		if tokenizer.Segmenter != nil {
+			tokens = append(tokens, tokenizer.Segmenter.Segment(token)...)
+			return
+		}
+
+		for _, r := range token {
+			tokens = append(tokens, string(r))
 		}
 	}
 
-	// Calculate the percentage of modules that aren't translated in the given locale
-	coverage := calculateCoverage(len(notCoveredMessages), len(defaultMessages))
+	for i, r := range runes {
+		inWord := unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+		if r == '\'' || r == '·' {
+			inWord = start >= 0 && i+1 < len(runes) && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+		}
 
-	return CoverageDetails{
-		NotCovered: notCoveredMessages,
-		Coverage:   coverage,
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			addToken(i)
+			start = -1
+		// The marks belong to the character before them
+		case inWord && !unicode.IsMark(r) && IsCJK(r) != IsCJK(runes[start]):
+			addToken(i)
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		addToken(len(runes))
 	}
+
+	return tokens
 }
 
-func getIntentCoverage(locale string) CoverageDetails {
-	var notCoveredIntents []string
+// IsCJK reports whether the character belongs to a script which doesn't separate its words
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
 
-	// Iterate through the default intents which are the english ones to verify if an intent isn't
-	// translated in the given locale.
-	for _, defaultIntent := range defaultIntents {
-		intent := GetIntentByTag(defaultIntent.Tag, locale)
+// NewDictionarySegmenter creates a segmenter which knows the given words
+func NewDictionarySegmenter(words []string) *DictionarySegmenter {
+	segmenter := &DictionarySegmenter{words: map[string]bool{}}
 
-		// Add the current module tag to the list of not-covered-modules
-		if intent.Tag != defaultIntent.Tag {
-			notCoveredIntents = append(notCoveredIntents, defaultIntent.Tag)
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
 		}
+
+		segmenter.words[word] = true
+		segmenter.maxLength = max(segmenter.maxLength, utf8.RuneCountInString(word))
 	}
 
-	// Calculate the percentage of modules that aren't translated in the given locale
-	coverage := calculateCoverage(len(notCoveredIntents), len(defaultModules))
+	return segmenter
+}
 
-	return CoverageDetails{
-		NotCovered: notCoveredIntents,
-		Coverage:   coverage,
+// ReadDictionarySegmenter creates the segmenter of the locale from ../res/locales/<locale>/dictionary.txt,
+// one word per line, and from the names of the countries. It returns nil when the locale has no
+// dictionary.
+func ReadDictionarySegmenter(locale string) Segmenter {
+	content, err := os.ReadFile(resourcePath(filepath.Join(localesDirectory, locale, "dictionary.txt")))
+	if err != nil {
+		return nil
+	}
+
+	words := strings.Split(string(content), "\n")
+	for _, country := range countries {
+		words = append(words, country.Name[locale])
 	}
+
+	return NewDictionarySegmenter(words)
 }
 
-func getModuleCoverage(locale string) CoverageDetails {
-	var notCoveredModules []string
+// Segment splits the text in the fewest words of the dictionary, the characters which aren't part
+// of a known word are words on their own and cost twice as much
+func (segmenter *DictionarySegmenter) Segment(text string) []string {
+	runes := []rune(text)
 
-	// Iterate through the default modules which are the english ones to verify if a module isn't
-	// translated in the given locale.
-	for _, defaultModule := range defaultModules {
-		module := GetModuleByTagf(defaultModule.Tag, locale)
+	// costs[i] is the cost of the best split of the first i characters, starts[i] the start of
+	// its last word and counts[i] its number of words
+	costs := make([]int, len(runes)+1)
+	starts := make([]int, len(runes)+1)
+	counts := make([]int, len(runes)+1)
+	for end := 1; end <= len(runes); end++ {
+		costs[end], starts[end] = costs[end-1]+2, end-1
 
-		// Add the current module tag to the list of not-covered-modules
-		if module.Tag != defaultModule.Tag {
-			notCoveredModules = append(notCoveredModules, defaultModule.Tag)
+		for start := max(0, end-segmenter.maxLength); start < end; start++ {
+			if costs[start]+1 < costs[end] && segmenter.words[string(runes[start:end])] {
+				costs[end], starts[end] = costs[start]+1, start
+			}
 		}
-	}
 
-	// Calculate the percentage of modules that aren't translated in the given locale
-	coverage := calculateCoverage(len(notCoveredModules), len(defaultModules))
+		counts[end] = counts[starts[end]] + 1
+	}
 
-	return CoverageDetails{
-		NotCovered: notCoveredModules,
-		Coverage:   coverage,
+	words := make([]string, counts[len(runes)])
+	for end, i := len(runes), len(words)-1; end > 0; end, i = starts[end], i-1 {
+		words[i] = string(runes[starts[end]:end])
 	}
+
+	return words
 }
 
-func calculateCoverage(notCoveredLength, defaultLength int) int {
-	return 100 * (defaultLength - notCoveredLength) / defaultLength
+// ReadStopWords reads the stopwords of ../res/locales/<locale>/stopwords.txt, one per line. The set
+// is empty when the locale has no stopwords file.
+func ReadStopWords(locale string) StopWordSet {
+	stopWords := StopWordSet{}
+	for _, line := range readStopWordLines(locale) {
+		if stopWord := strings.ToLower(strings.TrimSpace(line)); stopWord != "" {
+			stopWords[stopWord] = true
+		}
+	}
+
+	return stopWords
 }
 
-func (sentence *Sentence) arrange() {
-	// Remove punctuation after letters
-	punctuationRegex := regexp.MustCompile(`[a-zA-Z]( )?(\.|\?|!|¿|¡)`)
-	sentence.Content = punctuationRegex.ReplaceAllStringFunc(sentence.Content, func(s string) string {
-		punctuation := regexp.MustCompile(`(\.|\?|!)`)
-		return punctuation.ReplaceAllString(s, "")
-	})
+// readStopWordLines returns the lines of the stopwords file of the locale, none when it is missing
+func readStopWordLines(locale string) []string {
+	content, err := os.ReadFile(resourcePath(filepath.Join(localesDirectory, locale, "stopwords.txt")))
+	if err != nil {
+		return nil
+	}
 
-	sentence.Content = strings.ReplaceAll(sentence.Content, "-", " ")
-	sentence.Content = strings.TrimSpace(sentence.Content)
+	return strings.Split(string(content), "\n")
 }
 
-func removeStopWords(locale string, words []string) []string {
+// Filter removes the tokens which are exactly stopwords
+func (stopWords StopWordSet) Filter(tokens []string) []string {
 	// Don't remove stopwords for small sentences like “How are you” because it will remove all the words
-	if len(words) <= 4 {
-		return words
+	if len(tokens) <= 4 {
+		return tokens
 	}
 
-	// Read the content of the stopwords file
-	stopWords := string(FetchFileContent("../res/locales/" + locale + "/stopwords.txt"))
+	var filteredTokens []string
+	for _, token := range tokens {
+		if !stopWords[token] {
+			filteredTokens = append(filteredTokens, token)
+		}
+	}
 
-	var wordsToRemove []string
+	return filteredTokens
+}
 
-	// Iterate through all the stopwords
-	for _, stopWord := range strings.Split(stopWords, "\n") {
-		// Iterate through all the words of the given array
-		for _, word := range words {
-			// Continue if the word isn't a stopword
-			if !strings.Contains(stopWord, word) {
-				continue
-			}
+// NewSpellingCorrector creates a corrector which knows the words, the tokens are corrected with at
+// most maxDistance edits
+func NewSpellingCorrector(maxDistance int, words []string) *SpellingCorrector {
+	corrector := &SpellingCorrector{
+		MaxDistance: maxDistance,
+		known:       map[string]bool{},
+		words:       NewBKTree(),
+	}
 
-			wordsToRemove = append(wordsToRemove, word)
-		}
+	for _, word := range words {
+		corrector.known[word] = true
+		corrector.words.Add(word)
 	}
 
-	return SliceDifference(words, wordsToRemove)
+	return corrector
 }
 
-func (sentence Sentence) tokenize() (tokens []string) {
-	// Split the sentence in words
-	tokens = strings.Fields(sentence.Content)
+// Correct replaces the unknown tokens by the closest known word, a misspelled word which happens
+// to be a name like "helo" is corrected too. The tokens which aren't close to any word are then
+// corrected with the names at a single edit since there are too many of them to search farther.
+// The short tokens are allowed one edit for every three characters.
+func (corrector *SpellingCorrector) Correct(tokens []string) ([]string, []Correction) {
+	var corrections []Correction
 
-	// Lower case each word
 	for i, token := range tokens {
-		tokens[i] = strings.ToLower(token)
+		maxDistance := min(corrector.MaxDistance, utf8.RuneCountInString(token)/3)
+		if corrector.known[token] || maxDistance == 0 || strings.ContainsFunc(token, unicode.IsDigit) {
+			continue
+		}
+
+		// A transposition is two edits for the Levenshtein distance of the tree
+		word, found := closestWord(token, corrector.words.Search(token, maxDistance+1), maxDistance)
+		if !found && corrector.names != nil {
+			word, found = closestWord(token, corrector.names.Search(token, 1), 1)
+		}
+
+		if found && word != token {
+			corrections = append(corrections, Correction{From: token, To: word})
+			tokens[i] = word
+		}
 	}
 
-	tokens = removeStopWords(sentence.Locale, tokens)
+	return tokens, corrections
+}
 
-	return
+// closestWord returns the match with the smallest Damerau-Levenshtein distance to token, the ties
+// are broken by the Jaro-Winkler similarity
+func closestWord(token string, matches []FuzzyMatch, maxDistance int) (closest string, found bool) {
+	closestDistance, closestSimilarity := maxDistance+1, 0.0
+
+	for _, match := range matches {
+		distance := DamerauLevenshteinDistance(token, match.Word)
+		if distance > closestDistance {
+			continue
+		}
+
+		similarity := JaroWinklerSimilarity(token, match.Word)
+		if distance < closestDistance || similarity > closestSimilarity {
+			closest, closestDistance, closestSimilarity = match.Word, distance, similarity
+		}
+	}
+
+	return closest, closestDistance <= maxDistance
 }
 
-func (sentence Sentence) stem() (tokenizeWords []string) {
-	locale := GetTagByName(sentence.Locale)
-	// Set default locale to english
-	if locale == "" {
-		locale = "english"
+func GetSpellingBudget(locale string) int {
+	if budget, exists := SpellingBudgets[locale]; exists {
+		return budget
 	}
 
-	tokens := sentence.tokenize()
+	return DefaultSpellingBudget
+}
+
+// GetSnowballStemmer returns the stemmer of the language, the stemmers are shared by all the
+// pipelines
+func GetSnowballStemmer(language string) (*SnowballStemmer, error) {
+	if stemmer, exists := stemmers.Load(language); exists {
+		return stemmer.(*SnowballStemmer), nil
+	}
 
-	stemmer, err := snowball.New(locale)
+	snowballStemmer, err := snowball.New(language)
 	if err != nil {
-		fmt.Println("Stemmer error", err)
-		return
+		return nil, err
 	}
 
-	// Get the string token and push it to tokenizeWord
-	for _, tokenizeWord := range tokens {
-		word := stemmer.Stem(tokenizeWord)
-		tokenizeWords = append(tokenizeWords, word)
+	stemmer, loaded := stemmers.LoadOrStore(language, &SnowballStemmer{stemmer: snowballStemmer})
+	if loaded {
+		snowballStemmer.Close()
 	}
 
-	return
+	return stemmer.(*SnowballStemmer), nil
+}
+
+// Stem returns the stem of the word, the snowball stemmers keep their result in a buffer so
+// they can't stem two words at once
+func (stemmer *SnowballStemmer) Stem(word string) string {
+	stemmer.mutex.Lock()
+	defer stemmer.mutex.Unlock()
+
+	return stemmer.stemmer.Stem(word)
+}
+
+// Tokenize returns the words of the sentence without the stopwords, before they are stemmed
+func (context *NLPContext) Tokenize(sentence Sentence) []string {
+	pipeline := context.Pipeline
+	pipeline.Stemmer = nil
+
+	return pipeline.Process(sentence.Content)
+}
+
+// Stem returns the stems of the words of the sentence
+func (context *NLPContext) Stem(sentence Sentence) []string {
+	return context.Pipeline.Process(sentence.Content)
+}
+
+// WordsBag returns 1 for each word of the vocabulary which is in the sentence and 0 for the others
+func (context *NLPContext) WordsBag(sentence Sentence) []float64 {
+	bag, _ := context.CorrectedWordsBag(sentence)
+	return bag
+}
+
+// CorrectedWordsBag returns the words bag of the sentence and the corrections of its misspelled
+// words
+func (context *NLPContext) CorrectedWordsBag(sentence Sentence) ([]float64, []Correction) {
+	bag := make([]float64, len(context.Words))
+
+	stems, corrections := context.Pipeline.Analyze(sentence.Content)
+	for _, word := range stems {
+		if i, exists := context.WordIndexes[word]; exists {
+			bag[i] = 1
+		}
+	}
+
+	return bag, corrections
+}
+
+func (sentence Sentence) tokenize() []string {
+	return GetNLPContext(sentence.Locale).Tokenize(sentence)
+}
+
+func (sentence Sentence) stem() []string {
+	return GetNLPContext(sentence.Locale).Stem(sentence)
 }
 
 func (sentence Sentence) WordsBag(words []string) (bag []float64) {
+	stems := sentence.stem()
+
 	for _, word := range words {
 		// Append 1 if the patternWords contains the actual word, else 0
 		var valueToAppend float64
-		if SliceIncludes(sentence.stem(), word) {
+		if SliceIncludes(stems, word) {
 			valueToAppend = 1
 		}
 
@@ -928,15 +3720,21 @@ func (sentence Sentence) WordsBag(words []string) (bag []float64) {
 }
 
 func CacheIntents(locale string, _intents []Intent) {
+	intentsMutex.Lock()
+	defer intentsMutex.Unlock()
+
 	intents[locale] = _intents
 }
 
 func GetIntents_l(locale string) []Intent {
+	intentsMutex.RLock()
+	defer intentsMutex.RUnlock()
+
 	return intents[locale]
 }
 
 func SerializeIntents(locale string) (_intents []Intent) {
-	err := json.Unmarshal(FetchFileContent("../res/locales/"+locale+"/intents.json"), &_intents)
+	_intents, err := ReadIntents(locale)
 	if err != nil {
 		panic(err)
 	}
@@ -946,8 +3744,102 @@ func SerializeIntents(locale string) (_intents []Intent) {
 	return _intents
 }
 
+// ReadIntents reads ../res/locales/<locale>/intents.json and checks the entities of their slots
+func ReadIntents(locale string) (_intents []Intent, err error) {
+	content, err := os.ReadFile(resourcePath("../res/locales/" + locale + "/intents.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(content, &_intents); err != nil {
+		return nil, fmt.Errorf("the intents of %q are invalid: %w", locale, err)
+	}
+
+	// Check the slots now rather than when a sentence needs them
+	for i, intent := range _intents {
+		// The empty texts keep the positions of the ones which aren't translated, see
+		// mergeTranslatedList
+		intent.Patterns = slices.DeleteFunc(intent.Patterns, isEmptyText)
+		intent.Responses = slices.DeleteFunc(intent.Responses, isEmptyText)
+		_intents[i] = intent
+
+		if intent.Tag == "" {
+			return nil, fmt.Errorf("an intent of %q has no tag", locale)
+		}
+		if len(intent.Responses) == 0 {
+			return nil, fmt.Errorf("the intent %q of %q has no responses", intent.Tag, locale)
+		}
+
+		for _, slot := range intent.Slots {
+			if _, exists := entityExtractors[slot.Entity]; !exists {
+				return nil, fmt.Errorf("the slot %q of the intent %q has an unknown entity %q", slot.Name, intent.Tag, slot.Entity)
+			}
+		}
+	}
+
+	return _intents, nil
+}
+
+// chainIntents appends the intents of the next locales of the chain which aren't translated in the
+// locale's intents
+func chainIntents(locale string, _intents []Intent) []Intent {
+	tags := map[string]bool{}
+	for _, intent := range _intents {
+		tags[intent.Tag] = true
+	}
+
+	for _, fallback := range LocaleChain(locale) {
+		if fallback == locale {
+			continue
+		}
+
+		// The fallback is read again since its intents may not be cached yet
+		fallbackIntents, err := ReadIntents(fallback)
+		if err != nil {
+			continue
+		}
+
+		for _, intent := range fallbackIntents {
+			if !tags[intent.Tag] {
+				_intents = append(_intents, intent)
+				tags[intent.Tag] = true
+			}
+		}
+	}
+
+	return _intents
+}
+
+// GetChainModulesf returns the modules of the locale and the ones of the next locales of its chain
+// which it doesn't translate
+func GetChainModulesf(locale string) (_modules []Modulef) {
+	tags := map[string]bool{}
+	for _, chainLocale := range LocaleChain(locale) {
+		for _, module := range modulesf[chainLocale] {
+			if !tags[module.Tag] {
+				_modules = append(_modules, module)
+				tags[module.Tag] = true
+			}
+		}
+	}
+
+	return
+}
+
+// SerializeModulesIntents returns the intents of the locale's own modules, the ones of the next
+// locales of its chain aren't trained with its patterns
 func SerializeModulesIntents(locale string) []Intent {
-	registeredModules := GetModulesf(locale)
+	return modulesIntents(GetModulesf(locale))
+}
+
+// TrainedIntents returns the intents and the modules of the locale which its network is trained
+// with. The ones of the next locales of its chain are only used for their responses.
+func TrainedIntents(locale string) []Intent {
+	return append(SerializeIntents(locale), SerializeModulesIntents(locale)...)
+}
+
+// modulesIntents returns the intents made of the patterns and the responses of the modules
+func modulesIntents(registeredModules []Modulef) []Intent {
 	intents := make([]Intent, len(registeredModules))
 
 	for k, module := range registeredModules {
@@ -974,32 +3866,54 @@ func GetIntentByTag(tag, locale string) Intent {
 	return Intent{}
 }
 
+// Organize returns the vocabulary, the intents and the documents of the locale's patterns with
+// their variants when the augmentation of its training is enabled
 func Organize(locale string) (words, classes []string, documents []Document) {
-	// Append the modules intents to the intents from ../res/datasets/intents.json
-	intents := append(
-		SerializeIntents(locale),
-		SerializeModulesIntents(locale)...,
-	)
+	return OrganizeAugmented(locale, GetTrainingConfig(locale).Augmentation)
+}
+
+// OrganizeAugmented returns the vocabulary, the intents and the documents of the locale's patterns
+// with at most maxVariants variants for each of them
+func OrganizeAugmented(locale string, maxVariants int) (words, classes []string, documents []Document) {
+	// Read the resources again since they can have changed since the last training
+	_intents := TrainedIntents(locale)
+	context := CompileNLPContext(locale, nil, nil)
+
+	var augmentation Augmentation
+	if maxVariants > 0 {
+		var err error
+		// The patterns are still augmented with word dropouts and swaps
+		if augmentation, err = ReadAugmentation(locale); err != nil {
+			fmt.Println(color.FgRed.Render(err.Error()))
+		}
+	}
 
-	for _, intent := range intents {
+	for _, intent := range _intents {
 		for _, pattern := range intent.Patterns {
 			// Tokenize the pattern's sentence
 			patternSentence := Sentence{locale, pattern}
 			patternSentence.arrange()
 
-			// Add each word to response
-			for _, word := range patternSentence.stem() {
+			sentences := []Sentence{patternSentence}
+			for _, variant := range augmentation.AugmentPattern(patternSentence.Content, maxVariants) {
+				sentences = append(sentences, Sentence{locale, variant})
+			}
 
-				if !SliceIncludes(words, word) {
-					words = append(words, word)
+			for _, sentence := range sentences {
+				// Add each word to response
+				for _, word := range context.Stem(sentence) {
+
+					if !SliceIncludes(words, word) {
+						words = append(words, word)
+					}
 				}
-			}
 
-			// Add a new document
-			documents = append(documents, Document{
-				patternSentence,
-				intent.Tag,
-			})
+				// Add a new document
+				documents = append(documents, Document{
+					sentence,
+					intent.Tag,
+				})
+			}
 		}
 
 		// Add the intent tag to classes
@@ -1012,6 +3926,180 @@ func Organize(locale string) (words, classes []string, documents []Document) {
 	return words, classes, documents
 }
 
+// ReadAugmentation reads the tables of ../res/locales/<locale>/augmentation.json, the locales
+// without it are only augmented with word dropouts and swaps
+func ReadAugmentation(locale string) (augmentation Augmentation, err error) {
+	content, err := os.ReadFile(resourcePath(filepath.Join(localesDirectory, locale, "augmentation.json")))
+	if errors.Is(err, fs.ErrNotExist) {
+		return augmentation, nil
+	} else if err != nil {
+		return augmentation, err
+	}
+
+	if augmentation, err = ParseAugmentation(content); err != nil {
+		return augmentation, fmt.Errorf("the augmentation of %q is invalid: %w", locale, err)
+	}
+
+	return augmentation, nil
+}
+
+// ParseAugmentation decodes the tables of an augmentation.json and indexes them
+func ParseAugmentation(content []byte) (augmentation Augmentation, err error) {
+	if err = json.Unmarshal(content, &augmentation); err != nil {
+		return augmentation, err
+	}
+
+	augmentation.synonyms = map[string][]string{}
+	for _, group := range augmentation.Synonyms {
+		for _, word := range group {
+			augmentation.synonyms[strings.ToLower(word)] = group
+		}
+	}
+
+	augmentation.neighbors = map[rune][]rune{}
+	for _, row := range augmentation.Keyboard {
+		keys := []rune(row)
+		for i, key := range keys {
+			if i > 0 {
+				augmentation.neighbors[key] = append(augmentation.neighbors[key], keys[i-1])
+			}
+			if i+1 < len(keys) {
+				augmentation.neighbors[key] = append(augmentation.neighbors[key], keys[i+1])
+			}
+		}
+	}
+
+	return augmentation, nil
+}
+
+// AugmentPattern returns at most maxVariants new sentences made from the pattern by replacing a
+// word by a synonym, dropping a word, swapping two adjacent words or making a typo. The random
+// source is seeded with the pattern so the same variants are generated at each training.
+func (augmentation Augmentation) AugmentPattern(pattern string, maxVariants int) (variants []string) {
+	words := strings.Fields(pattern)
+	if maxVariants <= 0 || len(words) == 0 {
+		return nil
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(pattern))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	seen := map[string]bool{strings.Join(words, " "): true}
+	for attempt := 0; attempt < augmentationAttempts*maxVariants && len(variants) < maxVariants; attempt++ {
+		variant := slices.Clone(words)
+
+		switch random.Intn(4) {
+		case 0:
+			augmentation.replaceSynonym(variant, random)
+		case 1:
+			// Keep at least two words so that the variant still means something
+			if len(variant) > 2 {
+				i := random.Intn(len(variant))
+				variant = slices.Delete(variant, i, i+1)
+			}
+		case 2:
+			if len(variant) > 1 {
+				i := random.Intn(len(variant) - 1)
+				variant[i], variant[i+1] = variant[i+1], variant[i]
+			}
+		case 3:
+			augmentation.makeTypo(variant, random)
+		}
+
+		if sentence := strings.Join(variant, " "); !seen[sentence] {
+			seen[sentence] = true
+			variants = append(variants, sentence)
+		}
+	}
+
+	return variants
+}
+
+// replaceSynonym replaces one of the words which have synonyms by another word of its group
+func (augmentation Augmentation) replaceSynonym(words []string, random *rand.Rand) {
+	var indexes []int
+	for i, word := range words {
+		if len(augmentation.synonyms[strings.ToLower(word)]) > 1 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 {
+		return
+	}
+
+	i := indexes[random.Intn(len(indexes))]
+	word := strings.ToLower(words[i])
+
+	// Only the other words of the group change the sentence
+	var synonyms []string
+	for _, synonym := range augmentation.synonyms[word] {
+		if strings.ToLower(synonym) != word {
+			synonyms = append(synonyms, synonym)
+		}
+	}
+
+	words[i] = synonyms[random.Intn(len(synonyms))]
+}
+
+// makeTypo replaces a letter of a word of at least four letters by one of its neighbors on the
+// keyboard
+func (augmentation Augmentation) makeTypo(words []string, random *rand.Rand) {
+	var indexes []int
+	for i, word := range words {
+		if utf8.RuneCountInString(word) >= 4 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) == 0 {
+		return
+	}
+
+	i := indexes[random.Intn(len(indexes))]
+	letters := []rune(words[i])
+	position := random.Intn(len(letters))
+
+	neighbors := augmentation.neighbors[unicode.ToLower(letters[position])]
+	if len(neighbors) == 0 {
+		return
+	}
+
+	letters[position] = neighbors[random.Intn(len(neighbors))]
+	words[i] = string(letters)
+}
+
+// PrintAugmentation prints the variants generated for the patterns of the intents and the modules
+// which the locale is trained with so that they can be reviewed. When the augmentation of the
+// training is disabled, previewVariants variants are printed for each pattern.
+func PrintAugmentation(locale string, maxVariants int) error {
+	augmentation, err := ReadAugmentation(locale)
+	if err != nil {
+		return err
+	}
+
+	if maxVariants <= 0 {
+		maxVariants = previewVariants
+	}
+
+	for _, intent := range TrainedIntents(locale) {
+		fmt.Println(color.FgMagenta.Render(intent.Tag))
+
+		for _, pattern := range intent.Patterns {
+			patternSentence := Sentence{locale, pattern}
+			patternSentence.arrange()
+
+			fmt.Printf("  %s\n", patternSentence.Content)
+			for _, variant := range augmentation.AugmentPattern(patternSentence.Content, maxVariants) {
+				fmt.Printf("    %s %s\n", color.FgGreen.Render("+"), variant)
+			}
+		}
+	}
+
+	return nil
+}
+
 func NewSentence(locale, content string) (sentence Sentence) {
 	sentence = Sentence{
 		Locale:  locale,
@@ -1022,11 +4110,37 @@ func NewSentence(locale, content string) (sentence Sentence) {
 	return
 }
 
-func (sentence Sentence) PredictTag(neuralNetwork Network) string {
-	words, classes, _ := Organize(sentence.Locale)
+// PredictTag returns the intent of the sentence, or DontUnderstand when the model isn't confident
+// enough
+func (sentence Sentence) PredictTag(neuralNetwork Network) (string, error) {
+	results, err := sentence.Predict(neuralNetwork)
+	if err != nil {
+		return "", err
+	}
+
+	return SelectTag(results, neuralNetwork.ConfidenceThreshold()), nil
+}
+
+// Predict returns the intents ranked by their score. The scores of a sigmoid output layer are
+// independent and don't sum to 1 like the probabilities of a softmax, see ConfidenceThreshold. A
+// sentence without any known word has no confidence.
+func (sentence Sentence) Predict(neuralNetwork Network) ([]Result, error) {
+	context, err := neuralNetwork.Context()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := neuralNetwork.Classes
+	bag, corrections := context.CorrectedWordsBag(sentence)
 
 	// Predict with the model
-	predict := neuralNetwork.Predict(sentence.WordsBag(words))
+	predict := neuralNetwork.Predict(bag)
+
+	// The intent would only be guessed from the biases
+	scale := 1.0
+	if !slices.ContainsFunc(bag, func(x float64) bool { return x != 0 }) {
+		scale = 0
+	}
 
 	// Enumerate the results with the intent tags
 	var resultsTag []Result
@@ -1034,28 +4148,62 @@ func (sentence Sentence) PredictTag(neuralNetwork Network) string {
 		if i >= len(classes) {
 			continue
 		}
-		resultsTag = append(resultsTag, Result{classes[i], result})
+		resultsTag = append(resultsTag, Result{classes[i], result * scale})
 	}
 
-	// Sort the results in ascending order
-	sort.Slice(resultsTag, func(i, j int) bool {
+	// Sort the results in descending order
+	sort.SliceStable(resultsTag, func(i, j int) bool {
 		return resultsTag[i].Value > resultsTag[j].Value
 	})
 
-	LogResults(sentence.Locale, sentence.Content, resultsTag)
+	LogResults(sentence.Locale, sentence.Content, resultsTag, corrections...)
 
-	return resultsTag[0].Tag
+	return resultsTag, nil
 }
 
-func RandomizeResponse(locale, entry, tag, token string) (string, string) {
-	if tag == DontUnderstand {
-		return DontUnderstand, SelectRandomMessage(locale, tag)
+// SelectTag returns the tag of the best result, or DontUnderstand when its confidence is below the
+// threshold
+func SelectTag(results []Result, threshold float64) string {
+	if len(results) == 0 || results[0].Value == 0 || results[0].Value < threshold {
+		return DontUnderstand
 	}
 
-	// Append the modules intents to the intents from ../res/datasets/intents.json
-	intents := append(SerializeIntents(locale), SerializeModulesIntents(locale)...)
+	return results[0].Tag
+}
 
-	for _, intent := range intents {
+// ConfidenceThreshold returns the threshold of the network's locale, or DefaultConfidenceThreshold
+// when it is set, or the threshold of the loss the network was trained with
+func (network Network) ConfidenceThreshold() float64 {
+	if threshold, exists := ConfidenceThresholds[network.Locale]; exists {
+		return threshold
+	}
+
+	if DefaultConfidenceThreshold > 0 {
+		return DefaultConfidenceThreshold
+	}
+
+	return LossConfidenceThresholds[network.GetLoss()]
+}
+
+// RandomizeResponse returns the tag and a response of the intent with the values of its slots
+// found in the entry, the prompt of the first required slot which isn't found replaces the response
+func RandomizeResponse(locale, entry, tag, token string) (string, string, map[string]string) {
+	responseTag, response, slots, replaceable := chooseResponse(locale, entry, tag, token)
+	if replaceable {
+		responseTag, response = ReplaceContentf(locale, responseTag, entry, response, token)
+	}
+
+	return responseTag, response, slots
+}
+
+// chooseResponse is RandomizeResponse without the modules, replaceable is true when the response is
+// the one of the intent and the module of the tag must still be applied to it
+func chooseResponse(locale, entry, tag, token string) (string, string, map[string]string, bool) {
+	if tag == DontUnderstand {
+		return DontUnderstand, SelectRandomMessage(locale, tag), nil, false
+	}
+
+	for _, intent := range GetNLPContext(locale).Intents {
 		if intent.Tag != tag {
 			continue
 		}
@@ -1063,39 +4211,97 @@ func RandomizeResponse(locale, entry, tag, token string) (string, string) {
 		// Reply a "don't understand" message if the context isn't correct
 		cacheTag, _ := userCache.Get(token)
 		if intent.Context != "" && cacheTag != intent.Context {
-			return DontUnderstand, SelectRandomMessage(locale, DontUnderstand)
+			return DontUnderstand, SelectRandomMessage(locale, DontUnderstand), nil, false
 		}
 
 		// Set the actual context
 		userCache.Set(token, tag, gocache.DefaultExpiration)
 
+		slots := ExtractSlots(locale, entry, intent.Slots)
+		for _, slot := range intent.Slots {
+			if _, found := slots[slot.Name]; !slot.Required || found {
+				continue
+			}
+
+			if slot.Prompt == "" {
+				return DontUnderstand, SelectRandomMessage(locale, DontUnderstand), slots, false
+			}
+
+			return tag, FillSlots(slot.Prompt, intent.Slots, slots), slots, false
+		}
+
+		// The intents read from the files have responses, not always the ones given to SetContext
+		if len(intent.Responses) == 0 {
+			return DontUnderstand, SelectRandomMessage(locale, DontUnderstand), slots, false
+		}
+
 		// Choose a random response in intents
 		response := intent.Responses[0]
 		if len(intent.Responses) > 1 {
-			rand.Seed(time.Now().UnixNano())
-			response = intent.Responses[rand.Intn(len(intent.Responses))]
+			response = intent.Responses[ResponseRandom(len(intent.Responses))]
+		}
+		return tag, FillSlots(response, intent.Slots, slots), slots, true
+	}
+
+	return DontUnderstand, SelectRandomMessage(locale, DontUnderstand), nil, false
+}
+
+// Calculate returns the tag and the response for the sentence with the ranked results of the
+// prediction and the values of the intent's slots
+func (sentence Sentence) Calculate(
+	cache gocache.Cache, neuralNetwork Network, token string,
+) (string, string, []Result, map[string]string) {
+	responseTag, response, results, slots, replaceable := sentence.calculate(cache, neuralNetwork, token)
+	if replaceable {
+		responseTag, response = ReplaceContentf(sentence.Locale, responseTag, sentence.Content, response, token)
+	}
+
+	return responseTag, response, results, slots
+}
+
+// calculate is Calculate without the modules, see chooseResponse
+func (sentence Sentence) calculate(
+	cache gocache.Cache, neuralNetwork Network, token string,
+) (string, string, []Result, map[string]string, bool) {
+	key := cachedPredictionKey(sentence)
+	results, found := cache.Get(key)
+
+	// Predict the results with the neural network if the sentence isn't in the cache
+	if !found {
+		prediction, err := sentence.Predict(neuralNetwork)
+		if err != nil {
+			// Without results the "don't understand" message is sent
+			fmt.Println(color.FgRed.Render(err.Error()))
+		} else {
+			cache.Set(key, prediction, gocache.DefaultExpiration)
 		}
 
-		// And then apply the triggers on the message
-		return ReplaceContentf(locale, tag, entry, response, token)
+		results = prediction
 	}
 
-	return DontUnderstand, SelectRandomMessage(locale, DontUnderstand)
+	tag := SelectTag(results.([]Result), neuralNetwork.ConfidenceThreshold())
+	responseTag, response, slots, replaceable := chooseResponse(sentence.Locale, sentence.Content, tag, token)
+
+	return responseTag, response, results.([]Result), slots, replaceable
 }
 
-func (sentence Sentence) Calculate(cache gocache.Cache, neuralNetwork Network, token string) (string, string) {
-	tag, found := cache.Get(sentence.Content)
+// cachedPredictionKey is the key of the sentence's results in the cache of Calculate, the same
+// sentence has different intents in each locale
+func cachedPredictionKey(sentence Sentence) string {
+	return sentence.Locale + ":" + sentence.Content
+}
 
-	// Predict tag with the neural network if the sentence isn't in the cache
-	if !found {
-		tag = sentence.PredictTag(neuralNetwork)
-		cache.Set(sentence.Content, tag, gocache.DefaultExpiration)
+// FlushCachedPredictions removes the cached results of the locale's sentences, they were predicted
+// with the network or the intents which are replaced
+func FlushCachedPredictions(locale string) {
+	for key := range cacheInstance.Items() {
+		if strings.HasPrefix(key, locale+":") {
+			cacheInstance.Delete(key)
+		}
 	}
-
-	return RandomizeResponse(sentence.Locale, sentence.Content, tag.(string), token)
 }
 
-func LogResults(locale, entry string, results []Result) {
+func LogResults(locale, entry string, results []Result, corrections ...Correction) {
 	// If NO_LOGS is present, then don't print the given messages
 	if os.Getenv("NO_LOGS") == "1" {
 		return
@@ -1109,6 +4315,9 @@ func LogResults(locale, entry string, results []Result) {
 		color.FgCyan.Render(entry),
 		color.FgRed.Render(GetNameByTag(locale)),
 	)
+	for _, correction := range corrections {
+		fmt.Printf("  %s %s → %s\n", green("✎"), correction.From, yellow(correction.To))
+	}
 	for _, result := range results {
 		// Arbitrary choice of 0.004 to have less tags to show
 		if result.Value < 0.004 {
@@ -1319,6 +4528,160 @@ func GetTagByName(name string) string {
 	return ""
 }
 
+// NewLanguageIdentifier creates an identifier from sample texts of each locale
+func NewLanguageIdentifier(texts map[string][]string) *LanguageIdentifier {
+	identifier := &LanguageIdentifier{profiles: map[string]map[string]float64{}, unknown: map[string]float64{}}
+
+	counts := map[string]map[string]int{}
+	grams := map[string]bool{}
+	for locale, localeTexts := range texts {
+		counts[locale] = map[string]int{}
+		for _, text := range localeTexts {
+			for _, gram := range CharacterNGrams(text) {
+				counts[locale][gram]++
+				grams[gram] = true
+			}
+		}
+	}
+
+	// The probabilities are smoothed by counting each n-gram once more in every locale
+	for locale, localeCounts := range counts {
+		total := len(grams)
+		for _, count := range localeCounts {
+			total += count
+		}
+
+		identifier.profiles[locale] = make(map[string]float64, len(localeCounts))
+		for gram, count := range localeCounts {
+			identifier.profiles[locale][gram] = math.Log(float64(count+1) / float64(total))
+		}
+		identifier.unknown[locale] = math.Log(1 / float64(total))
+	}
+
+	return identifier
+}
+
+// ReadLanguageIdentifier creates an identifier from the intents and the stopwords of the locales,
+// their files are read again so that the intents of the locales aren't replaced
+func ReadLanguageIdentifier() *LanguageIdentifier {
+	texts := map[string][]string{}
+	for _, locale := range Locales {
+		_intents, err := ReadIntents(locale.Tag)
+		if err != nil {
+			fmt.Println(color.FgRed.Render(err.Error()))
+		}
+
+		for _, intent := range _intents {
+			texts[locale.Tag] = append(texts[locale.Tag], intent.Patterns...)
+			texts[locale.Tag] = append(texts[locale.Tag], intent.Responses...)
+		}
+
+		texts[locale.Tag] = append(texts[locale.Tag], readStopWordLines(locale.Tag)...)
+	}
+
+	return NewLanguageIdentifier(texts)
+}
+
+// CharacterNGrams returns the n-grams of one to three characters of the lower cased words of the
+// text, the words are surrounded by spaces to mark their start and their end
+func CharacterNGrams(text string) (grams []string) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && r != '\''
+	})
+
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= languageNGramSize; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if n == 1 && runes[i] == ' ' {
+					continue
+				}
+
+				grams = append(grams, string(runes[i:i+n]))
+			}
+		}
+	}
+
+	return grams
+}
+
+// Identify returns the locales ranked by the probability that the sentence is in their language,
+// it is empty when the sentence has no letter
+func (identifier *LanguageIdentifier) Identify(sentence string) []Result {
+	grams := CharacterNGrams(sentence)
+	if len(grams) == 0 || len(identifier.profiles) == 0 {
+		return nil
+	}
+
+	var results []Result
+	maxScore := math.Inf(-1)
+	for locale, profile := range identifier.profiles {
+		score := 0.0
+		for _, gram := range grams {
+			probability, exists := profile[gram]
+			if !exists {
+				probability = identifier.unknown[locale]
+			}
+
+			score += probability
+		}
+
+		results = append(results, Result{Tag: locale, Value: score})
+		maxScore = max(maxScore, score)
+	}
+
+	// Turn the log-likelihoods into probabilities
+	var sum float64
+	for i := range results {
+		results[i].Value = math.Exp(results[i].Value - maxScore)
+		sum += results[i].Value
+	}
+
+	for i := range results {
+		results[i].Value /= sum
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Value != results[j].Value {
+			return results[i].Value > results[j].Value
+		}
+
+		return results[i].Tag < results[j].Tag
+	})
+
+	return results
+}
+
+// DetectLocale returns the locale used to reply to the content and the language detected in it.
+// The requested locale is kept when it is supported, unless the detected language is more likely
+// than LanguageOverrideThreshold. English is used when nothing can be detected, nothing is detected
+// when a single locale is enabled.
+func DetectLocale(requested, content string) (string, *Result) {
+	supported := Exists(requested)
+
+	// There is nothing to choose from with a single locale
+	var results []Result
+	if len(Locales) > 1 {
+		results = languageIdentifier().Identify(content)
+	}
+
+	if len(results) == 0 {
+		if supported {
+			return requested, nil
+		}
+
+		return DefaultLocale, nil
+	}
+
+	detected := results[0]
+	if supported && requested != detected.Tag &&
+		(LanguageOverrideThreshold <= 0 || detected.Value < LanguageOverrideThreshold) {
+		return requested, &detected
+	}
+
+	return detected.Tag, &detected
+}
+
 func Exists(tag string) bool {
 	for _, locale := range Locales {
 		if locale.Tag == tag {
@@ -1329,6 +4692,101 @@ func Exists(tag string) bool {
 	return false
 }
 
+// NegotiateLocale returns the first supported locale of the chains of the tags of an
+// Accept-Language list like "fr-CA,fr;q=0.9", sorted by their quality, or "" when none of them is
+// supported. A single tag like en-GB or pt_BR is also accepted.
+func NegotiateLocale(accepted string) string {
+	accepted = strings.ReplaceAll(strings.TrimSpace(accepted), "_", "-")
+	if accepted == "" {
+		return ""
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(accepted)
+	if err != nil {
+		// Keep the tags which can be parsed
+		var valid []string
+		for _, entry := range strings.Split(accepted, ",") {
+			if _, _, err := language.ParseAcceptLanguage(entry); err == nil {
+				valid = append(valid, entry)
+			}
+		}
+
+		tags, _, _ = language.ParseAcceptLanguage(strings.Join(valid, ","))
+	}
+
+	for _, tag := range tags {
+		for _, candidate := range appendLocaleCandidates(nil, tag.String()) {
+			if Exists(candidate) {
+				return candidate
+			}
+		}
+	}
+
+	return ""
+}
+
+// LocaleChain returns the supported locales in which the resources of the locale are searched:
+// the locale, its fallbacks, its parent languages and DefaultLocale, e.g. fr-CA → fr → en
+func LocaleChain(locale string) (chain []string) {
+	for _, candidate := range appendLocaleCandidates(nil, locale) {
+		if Exists(candidate) {
+			chain = append(chain, candidate)
+		}
+	}
+
+	if !slices.Contains(chain, DefaultLocale) {
+		chain = append(chain, DefaultLocale)
+	}
+
+	return
+}
+
+// appendLocaleCandidates appends the tag, its LocaleFallbacks and its parent languages to the
+// candidates which don't already have them
+func appendLocaleCandidates(candidates []string, tag string) []string {
+	parsed, err := language.Parse(strings.ReplaceAll(tag, "_", "-"))
+	if err == nil {
+		tag = parsed.String()
+	}
+	if slices.Contains(candidates, tag) {
+		return candidates
+	}
+
+	candidates = append(candidates, tag)
+	for _, fallback := range LocaleFallbacks[tag] {
+		candidates = appendLocaleCandidates(candidates, fallback)
+	}
+
+	if err == nil && parsed.Parent() != language.Und {
+		candidates = appendLocaleCandidates(candidates, parsed.Parent().String())
+	}
+
+	return candidates
+}
+
+// GetRuleTranslation returns the date rules of the first locale of the chain which has them
+func GetRuleTranslation(locale string) RuleTranslation {
+	for _, candidate := range LocaleChain(locale) {
+		if translation, exists := RuleTranslations[candidate]; exists {
+			return translation
+		}
+	}
+
+	return RuleTranslation{}
+}
+
+// GetPatternTranslation returns the date and time patterns of the first locale of the chain which
+// has them
+func GetPatternTranslation(locale string) PatternTranslations {
+	for _, candidate := range LocaleChain(locale) {
+		if translation, exists := PatternTranslation[candidate]; exists {
+			return translation
+		}
+	}
+
+	return PatternTranslations{}
+}
+
 func SearchTime(locale, sentence string) (string, time.Time) {
 	_time := RuleTime(sentence)
 	// Set the time to 12am if no time has been found
@@ -1353,7 +4811,7 @@ func SearchTime(locale, sentence string) (string, time.Time) {
 
 func DeleteDates(locale, sentence string) string {
 	// Create a regex to match the patterns of dates to remove them.
-	datePatterns := regexp.MustCompile(PatternTranslation[locale].DateRegex)
+	datePatterns := regexp.MustCompile(GetPatternTranslation(locale).DateRegex)
 
 	// Replace the dates by empty string
 	sentence = datePatterns.ReplaceAllString(sentence, "")
@@ -1363,7 +4821,7 @@ func DeleteDates(locale, sentence string) string {
 
 func DeleteTimes(locale, sentence string) string {
 	// Create a regex to match the patterns of times to remove them.
-	timePatterns := regexp.MustCompile(PatternTranslation[locale].TimeRegex)
+	timePatterns := regexp.MustCompile(GetPatternTranslation(locale).TimeRegex)
 
 	// Replace the times by empty string
 	sentence = timePatterns.ReplaceAllString(sentence, "")
@@ -1375,10 +4833,134 @@ func RegisterRule(rule Rule) {
 	rules = append(rules, rule)
 }
 
+// RegisterEntity makes the extractor available to the slots of the intents under the entity name
+func RegisterEntity(entity string, extractor EntityExtractor) {
+	entityExtractors[entity] = extractor
+}
+
+// ExtractSlots returns the values of the slots found in the sentence by their entity extractor
+func ExtractSlots(locale, sentence string, slots []Slot) map[string]string {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for _, slot := range slots {
+		extractor, exists := entityExtractors[slot.Entity]
+		if !exists {
+			continue
+		}
+
+		if value, found := extractor(locale, sentence, slot); found {
+			values[slot.Name] = value
+		}
+	}
+
+	return values
+}
+
+// FillSlots replaces the {name} of each slot in the response by its value, the slots which
+// weren't found are removed
+func FillSlots(response string, slots []Slot, values map[string]string) string {
+	if len(slots) == 0 {
+		return response
+	}
+
+	replacements := make([]string, 0, 2*len(slots))
+	for _, slot := range slots {
+		replacements = append(replacements, "{"+slot.Name+"}", values[slot.Name])
+	}
+
+	return strings.NewReplacer(replacements...).Replace(response)
+}
+
+// ExtractDate returns the date of the sentence found by the rules as YYYY-MM-DD
+func ExtractDate(locale, sentence string, _ Slot) (string, bool) {
+	for _, rule := range rules {
+		if date := rule(locale, sentence); date != (time.Time{}) {
+			return date.Format("2006-01-02"), true
+		}
+	}
+
+	return "", false
+}
+
+// ExtractTime returns the time of the sentence as HH:MM
+func ExtractTime(_, sentence string, _ Slot) (string, bool) {
+	foundTime := RuleTime(sentence)
+	if foundTime == (time.Time{}) {
+		return "", false
+	}
+
+	return foundTime.Format("15:04"), true
+}
+
+// ExtractNumber returns the first number of the sentence which isn't part of a time or a date
+func ExtractNumber(locale, sentence string, _ Slot) (string, bool) {
+	number := numberRegex.FindString(DeleteDates(locale, DeleteTimes(locale, sentence)))
+	return number, number != ""
+}
+
+// ExtractCountry returns the name of the country of the sentence in the locale's language
+func ExtractCountry(locale, sentence string, _ Slot) (string, bool) {
+	country := FindCountry(locale, sentence)
+	return country.Name[locale], country.Name != nil
+}
+
+// ExtractName returns the capitalized first name of the sentence
+func ExtractName(locale, sentence string, _ Slot) (string, bool) {
+	name := FindName(sentence)
+	return cases.Title(language.Make(locale)).String(name), name != ""
+}
+
+// ExtractGenres returns the movies genres of the sentence separated by commas, unlike
+// FindMoviesGenres the short genres must be spelled exactly since any sentence is close to "War"
+func ExtractGenres(locale, sentence string, _ Slot) (string, bool) {
+	found := map[string]bool{}
+	for _, word := range strings.Split(strings.ToUpper(sentence), " ") {
+		genre, exists := keywordIndexes[locale].genres.Find(word)
+		if exists && LevenshteinDistance(word, genre) <= utf8.RuneCountInString(genre)/4 {
+			found[genre] = true
+		}
+	}
+
+	var genres []string
+	for _, genre := range MoviesGenres[locale] {
+		if found[strings.ToUpper(genre)] {
+			genres = append(genres, genre)
+		}
+	}
+
+	return strings.Join(genres, ", "), len(genres) > 0
+}
+
+// ExtractText returns the text which follows the keyword of the slot
+func ExtractText(_, sentence string, slot Slot) (string, bool) {
+	if slot.Keyword == "" {
+		return "", false
+	}
+
+	// The keyword is compared character by character since the case changes can change the size of
+	// the characters
+	keywordLength := utf8.RuneCountInString(slot.Keyword)
+	for index := range sentence {
+		end := index
+		for i := 0; i < keywordLength && end < len(sentence); i++ {
+			_, size := utf8.DecodeRuneInString(sentence[end:])
+			end += size
+		}
+
+		if strings.EqualFold(sentence[index:end], slot.Keyword) {
+			text := strings.TrimSpace(sentence[end:])
+			return text, text != ""
+		}
+	}
 
+	return "", false
+}
 
 func RuleToday(locale, sentence string) (result time.Time) {
-	todayRegex := regexp.MustCompile(RuleTranslations[locale].RuleToday)
+	todayRegex := regexp.MustCompile(GetRuleTranslation(locale).RuleToday)
 	today := todayRegex.FindString(sentence)
 
 	// Returns an empty date struct if no date has been found
@@ -1390,7 +4972,7 @@ func RuleToday(locale, sentence string) (result time.Time) {
 }
 
 func RuleTomorrow(locale, sentence string) (result time.Time) {
-	tomorrowRegex := regexp.MustCompile(RuleTranslations[locale].RuleTomorrow)
+	tomorrowRegex := regexp.MustCompile(GetRuleTranslation(locale).RuleTomorrow)
 	date := tomorrowRegex.FindString(sentence)
 
 	// Returns an empty date struct if no date has been found
@@ -1401,7 +4983,7 @@ func RuleTomorrow(locale, sentence string) (result time.Time) {
 	result = time.Now().Add(day)
 
 	// If the date contains "after", we add 24 hours to tomorrow's date
-	if strings.Contains(date, RuleTranslations[locale].RuleAfterTomorrow) {
+	if strings.Contains(date, GetRuleTranslation(locale).RuleAfterTomorrow) {
 		return result.Add(day)
 	}
 
@@ -1409,7 +4991,7 @@ func RuleTomorrow(locale, sentence string) (result time.Time) {
 }
 
 func RuleDayOfWeek(locale, sentence string) time.Time {
-	dayOfWeekRegex := regexp.MustCompile(RuleTranslations[locale].RuleDayOfWeek)
+	dayOfWeekRegex := regexp.MustCompile(GetRuleTranslation(locale).RuleDayOfWeek)
 	date := dayOfWeekRegex.FindString(sentence)
 
 	// Returns an empty date struct if no date has been found
@@ -1438,7 +5020,7 @@ func RuleDayOfWeek(locale, sentence string) time.Time {
 	}
 
 	// If there is "next" in the sentence, then we add another week
-	if strings.Contains(date, RuleTranslations[locale].RuleNextDayOfWeek) {
+	if strings.Contains(date, GetRuleTranslation(locale).RuleNextDayOfWeek) {
 		calculatedDate += 7
 	}
 
@@ -1448,7 +5030,7 @@ func RuleDayOfWeek(locale, sentence string) time.Time {
 
 func RuleNaturalDate(locale, sentence string) time.Time {
 	naturalMonthRegex := regexp.MustCompile(
-		RuleTranslations[locale].RuleNaturalDate,
+		GetRuleTranslation(locale).RuleNaturalDate,
 	)
 	naturalDayRegex := regexp.MustCompile(`\d{2}|\d`)
 
@@ -1457,7 +5039,7 @@ func RuleNaturalDate(locale, sentence string) time.Time {
 
 	// Put the month in english to parse the time with time golang package
 	if locale != "en" {
-		monthIndex := SliceIndex(RuleTranslations[locale].Months, month)
+		monthIndex := SliceIndex(GetRuleTranslation(locale).Months, month)
 		month = RuleTranslations["en"].Months[monthIndex]
 	}
 
@@ -1545,75 +5127,340 @@ func RuleTime(sentence string) time.Time {
 		hoursAndMinutesRegex := regexp.MustCompile(`(\d{2}|\d):(\d{2}|\d)`)
 		timeVariables := strings.Split(hoursAndMinutesRegex.FindString(foundTime), ":")
 
-		// Format the time with 2 digits for each
-		formattedTime := fmt.Sprintf("%02s:%02s %s", timeVariables[0], timeVariables[1], part)
-		response, _ := time.Parse("03:04 pm", formattedTime)
+		// Format the time with 2 digits for each
+		formattedTime := fmt.Sprintf("%02s:%02s %s", timeVariables[0], timeVariables[1], part)
+		response, _ := time.Parse("03:04 pm", formattedTime)
+
+		return response
+	}
+
+	digitsRegex := regexp.MustCompile(`\d{2}|\d`)
+	foundDigits := digitsRegex.FindString(foundTime)
+
+	formattedTime := fmt.Sprintf("%02s %s", foundDigits, part)
+	response, _ := time.Parse("03 pm", formattedTime)
+
+	return response
+}
+
+func SerializeCountries() (countries []Country) {
+	err := json.Unmarshal(FetchFileContent("../res/datasets/countries.json"), &countries)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return countries
+}
+
+func FindCountry(locale, sentence string) Country {
+	for _, country := range countries {
+		name, exists := country.Name[locale]
+
+		if !exists {
+			continue
+		}
+
+		// If the actual country isn't contained in the sentence, continue
+		if !strings.Contains(strings.ToLower(sentence), strings.ToLower(name)) {
+			continue
+		}
+
+		// Returns the right country
+		return country
+	}
+
+	// Returns an empty country if none has been found
+	return Country{}
+}
+
+// LevenshteinDistance returns the number of characters to insert, delete or substitute to change
+// first into second
+func LevenshteinDistance(first, second string) int {
+	firstRunes, secondRunes := []rune(first), []rune(second)
+
+	// Returns the length if it's empty
+	if len(firstRunes) == 0 {
+		return len(secondRunes)
+	}
+	if len(secondRunes) == 0 {
+		return len(firstRunes)
+	}
+
+	// Only the previous row of the distances is kept, the short words don't allocate it
+	var buffer [distanceBufferSize]int
+	row := distanceRow(buffer[:], len(secondRunes)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i, firstRune := range firstRunes {
+		// diagonal holds the distance between the first i and j characters
+		diagonal := row[0]
+		row[0] = i + 1
+
+		for j, secondRune := range secondRunes {
+			distance := diagonal
+			if firstRune != secondRune {
+				distance = min(diagonal, row[j], row[j+1]) + 1
+			}
+
+			diagonal, row[j+1] = row[j+1], distance
+		}
+	}
+
+	return row[len(secondRunes)]
+}
+
+// DamerauLevenshteinDistance returns the Levenshtein distance where swapping two adjacent
+// characters counts as one edit, in its optimal string alignment variant which doesn't edit
+// a substring twice
+func DamerauLevenshteinDistance(first, second string) int {
+	firstRunes, secondRunes := []rune(first), []rune(second)
+
+	// Returns the length if it's empty
+	if len(firstRunes) == 0 {
+		return len(secondRunes)
+	}
+	if len(secondRunes) == 0 {
+		return len(firstRunes)
+	}
+
+	// The transpositions need the row before the previous one
+	var buffer [3 * distanceBufferSize]int
+	rows := distanceRow(buffer[:], 3*(len(secondRunes)+1))
+	beforePrevious, previous, current := rows[:len(secondRunes)+1],
+		rows[len(secondRunes)+1:2*(len(secondRunes)+1)], rows[2*(len(secondRunes)+1):]
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i, firstRune := range firstRunes {
+		current[0] = i + 1
+
+		for j, secondRune := range secondRunes {
+			cost := 1
+			if firstRune == secondRune {
+				cost = 0
+			}
+
+			current[j+1] = min(previous[j]+cost, previous[j+1]+1, current[j]+1)
+			if i > 0 && j > 0 && firstRune == secondRunes[j-1] && firstRunes[i-1] == secondRune {
+				current[j+1] = min(current[j+1], beforePrevious[j-1]+1)
+			}
+		}
+
+		beforePrevious, previous, current = previous, current, beforePrevious
+	}
+
+	return previous[len(secondRunes)]
+}
+
+// distanceRow returns the buffer's first length values, or a new slice if it is too small
+func distanceRow(buffer []int, length int) []int {
+	if length > len(buffer) {
+		return make([]int, length)
+	}
+
+	return buffer[:length]
+}
+
+// JaroWinklerSimilarity returns the similarity between first and second, from 0 when they
+// have nothing in common to 1 when they are equal, favoring the strings with a common prefix
+func JaroWinklerSimilarity(first, second string) float64 {
+	firstRunes, secondRunes := []rune(first), []rune(second)
+	if len(firstRunes) == 0 && len(secondRunes) == 0 {
+		return 1
+	}
+	if len(firstRunes) == 0 || len(secondRunes) == 0 {
+		return 0
+	}
+
+	// The characters match if they are equal and not farther than the window
+	window := max(0, max(len(firstRunes), len(secondRunes))/2-1)
+
+	var buffer [2 * distanceBufferSize]bool
+	matched := buffer[:]
+	if len(firstRunes)+len(secondRunes) > len(matched) {
+		matched = make([]bool, len(firstRunes)+len(secondRunes))
+	}
+	firstMatched, secondMatched := matched[:len(firstRunes)], matched[len(firstRunes):]
+
+	matches := 0
+	for i, r := range firstRunes {
+		for j := max(0, i-window); j < min(len(secondRunes), i+window+1); j++ {
+			if !secondMatched[j] && secondRunes[j] == r {
+				firstMatched[i], secondMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// Count the matched characters which aren't in the same order
+	transpositions, j := 0, 0
+	for i, r := range firstRunes {
+		if !firstMatched[i] {
+			continue
+		}
 
-		return response
+		for !secondMatched[j] {
+			j++
+		}
+
+		if r != secondRunes[j] {
+			transpositions++
+		}
+		j++
 	}
 
-	digitsRegex := regexp.MustCompile(`\d{2}|\d`)
-	foundDigits := digitsRegex.FindString(foundTime)
+	m := float64(matches)
+	jaro := (m/float64(len(firstRunes)) + m/float64(len(secondRunes)) + (m-float64(transpositions)/2)/m) / 3
 
-	formattedTime := fmt.Sprintf("%02s %s", foundDigits, part)
-	response, _ := time.Parse("03 pm", formattedTime)
+	// The common prefix of up to four characters raises the similarity
+	prefix := 0
+	for prefix < min(4, len(firstRunes), len(secondRunes)) && firstRunes[prefix] == secondRunes[prefix] {
+		prefix++
+	}
 
-	return response
+	return jaro + float64(prefix)*jaroWinklerScaling*(1-jaro)
 }
 
-func SerializeCountries() (countries []Country) {
-	err := json.Unmarshal(FetchFileContent("../res/datasets/countries.json"), &countries)
-	if err != nil {
-		fmt.Println(err)
+// NewBKTree creates a BK-tree which finds the words close to a given one in the Levenshtein
+// distance without comparing it to all of them
+func NewBKTree(words ...string) *BKTree {
+	tree := &BKTree{}
+	for _, word := range words {
+		tree.Add(word)
 	}
 
-	return countries
+	return tree
 }
 
-func FindCountry(locale, sentence string) Country {
-	for _, country := range countries {
-		name, exists := country.Name[locale]
+// Add inserts the word in the tree if it isn't already in it
+func (tree *BKTree) Add(word string) {
+	if tree.root == nil {
+		tree.root = &bkNode{word: word}
+		tree.size++
+		return
+	}
 
-		if !exists {
-			continue
+	node := tree.root
+	for {
+		distance := LevenshteinDistance(word, node.word)
+		if distance == 0 {
+			return
 		}
 
-		// If the actual country isn't contained in the sentence, continue
-		if !strings.Contains(strings.ToLower(sentence), strings.ToLower(name)) {
-			continue
+		child, found := node.children[distance]
+		if !found {
+			if node.children == nil {
+				node.children = map[int]*bkNode{}
+			}
+
+			node.children[distance] = &bkNode{word: word}
+			tree.size++
+			return
 		}
 
-		// Returns the right country
-		return country
+		node = child
 	}
+}
 
-	// Returns an empty country if none has been found
-	return Country{}
+// Len returns the number of words in the tree
+func (tree *BKTree) Len() int {
+	return tree.size
 }
 
-func LevenshteinDistance(first, second string) int {
-	// Returns the length if it's empty
-	if first == "" {
-		return len(second)
+// Search returns the words at most maxDistance edits away from word, the closest first
+func (tree *BKTree) Search(word string, maxDistance int) (matches []FuzzyMatch) {
+	if tree.root == nil {
+		return nil
+	}
+
+	nodes := []*bkNode{tree.root}
+	for len(nodes) > 0 {
+		node := nodes[len(nodes)-1]
+		nodes = nodes[:len(nodes)-1]
+
+		distance := LevenshteinDistance(word, node.word)
+		if distance <= maxDistance {
+			matches = append(matches, FuzzyMatch{Word: node.word, Distance: distance})
+		}
+
+		// By the triangle inequality only the children within maxDistance of this distance can match
+		for childDistance, child := range node.children {
+			if childDistance >= distance-maxDistance && childDistance <= distance+maxDistance {
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+
+		return matches[i].Word < matches[j].Word
+	})
+
+	return matches
+}
+
+// NewKeywordIndex creates an index of the keywords with their maximum distance
+func NewKeywordIndex(maxDistances map[string]int) *KeywordIndex {
+	index := &KeywordIndex{words: NewBKTree(), maxDistances: maxDistances}
+	for keyword, maxDistance := range maxDistances {
+		index.words.Add(keyword)
+		index.maxDistance = max(index.maxDistance, maxDistance)
 	}
-	if second == "" {
-		return len(first)
+
+	return index
+}
+
+// Find returns the keyword closest to the word among the ones within their maximum distance, the
+// ties are broken like for the spelling correction
+func (index *KeywordIndex) Find(word string) (keyword string, found bool) {
+	if index == nil {
+		return "", false
 	}
 
-	if first[0] == second[0] {
-		return LevenshteinDistance(first[1:], second[1:])
+	var matches []FuzzyMatch
+	for _, match := range index.words.Search(word, index.maxDistance) {
+		if match.Distance <= index.maxDistances[match.Word] {
+			matches = append(matches, match)
+		}
 	}
 
-	a := LevenshteinDistance(first[1:], second[1:])
-	if b := LevenshteinDistance(first, second[1:]); a > b {
-		a = b
+	if len(matches) == 0 {
+		return "", false
 	}
 
-	if c := LevenshteinDistance(first[1:], second); a > c {
-		a = c
+	return closestWord(word, matches, index.maxDistance)
+}
+
+// newLocaleKeywords indexes the movies genres and the keywords of the pack with the distances
+// which were accepted for each of them
+func newLocaleKeywords(pack LocalePack) localeKeywords {
+	genres := map[string]int{}
+	for _, genre := range pack.MoviesGenres {
+		genres[strings.ToUpper(genre)] = 2
 	}
 
-	return a + 1
+	return localeKeywords{
+		genres: NewKeywordIndex(genres),
+		spotify: NewKeywordIndex(map[string]int{
+			pack.SpotifyKeywords.Play: 1,
+			pack.SpotifyKeywords.From: 1,
+		}),
+		reason: NewKeywordIndex(map[string]int{
+			pack.ReasonKeywords.That: 2,
+			pack.ReasonKeywords.To:   1,
+		}),
+	}
 }
 
 func LevenshteinContains(sentence, matching string, rate int) bool {
@@ -1703,8 +5550,16 @@ func SearchMovie(genre, userToken string) (output Movie) {
 }
 
 func FindMoviesGenres(locale, content string) (output []string) {
+	found := map[string]bool{}
+	for _, word := range strings.Split(strings.ToUpper(content), " ") {
+		if genre, exists := keywordIndexes[locale].genres.Find(word); exists {
+			found[genre] = true
+		}
+	}
+
+	// Keep the order of the genres, they are translated by their index
 	for i, genre := range MoviesGenres[locale] {
-		if LevenshteinContains(strings.ToUpper(content), strings.ToUpper(genre), 2) {
+		if found[strings.ToUpper(genre)] {
 			output = append(output, MoviesGenres["en"][i])
 		}
 	}
@@ -1728,8 +5583,10 @@ func SearchMusic(locale, sentence string) (music, artist string) {
 			artist += word + " "
 		}
 
+		keyword, found := keywordIndexes[locale].spotify.Find(word)
+
 		// If "from" appeared
-		if LevenshteinDistance(word, SpotifyKeyword[locale].From) < 2 {
+		if found && keyword == SpotifyKeyword[locale].From {
 			fromAppeared = true
 		}
 
@@ -1739,7 +5596,7 @@ func SearchMusic(locale, sentence string) (music, artist string) {
 		}
 
 		// If "play" appeared
-		if LevenshteinDistance(word, SpotifyKeyword[locale].Play) < 2 {
+		if found && keyword == SpotifyKeyword[locale].Play {
 			playAppeared = true
 		}
 	}
@@ -1810,8 +5667,7 @@ func SearchReason(locale, sentence string) string {
 
 		// If the keyword didn't appeared and one of the keywords match set the appeared condition
 		// to true
-		if !appeared && (LevenshteinDistance(word, ReasonKeywords[locale].That) <= 2 ||
-			LevenshteinDistance(word, ReasonKeywords[locale].To) < 2) {
+		if _, found := keywordIndexes[locale].reason.Find(word); !appeared && found {
 			appeared = true
 		}
 	}
@@ -1983,7 +5839,7 @@ func RemoveUserReminder(token string, index int) {
 
 func AdvicesReplacer(locale, entry, response, _ string) (string, string) {
 
-	resp, err := http.Get(adviceURL)
+	resp, err := moduleClient.Get(adviceURL)
 	if err != nil {
 		responseTag := "no advices"
 		return responseTag, SelectRandomMessage(locale, responseTag)
@@ -2048,7 +5904,7 @@ func CurrencyReplacer(locale, entry, response, _ string) (string, string) {
 
 func JokesReplacer(locale, entry, response, _ string) (string, string) {
 
-	resp, err := http.Get(jokeURL)
+	resp, err := moduleClient.Get(jokeURL)
 	if err != nil {
 		responseTag := "no jokes"
 		return responseTag, SelectRandomMessage(locale, responseTag)
@@ -2129,8 +5985,494 @@ func GetModuleByTagf(tag, locale string) Modulef {
 	return Modulef{}
 }
 
+// RegisterModuleReplacer registers the replacer of the modules with the given tag, the locale
+// packs which use the tag in their modules.json get it
+func RegisterModuleReplacer(tag string, replacer ModuleReplacer) {
+	moduleReplacers[tag] = replacer
+}
+
+// resourcePath returns the path of a resource from src/ or, if it doesn't exist, from a directory
+// under it like FetchFileContent
+func resourcePath(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		if _, err := os.Stat("../" + path); err == nil {
+			return "../" + path
+		}
+	}
+
+	return path
+}
+
+// LoadLocalePacks discovers the locale packs in res/locales and fills the locales, their tables
+// and their modules. The programs call it before using the locales, it fails if a pack is
+// incomplete.
+func LoadLocalePacks() error {
+	packs, err := ReadLocalePacks()
+	if err != nil {
+		return err
+	}
+
+	for _, pack := range packs {
+		InstallLocalePack(pack)
+	}
+
+	return nil
+}
+
+// ReadLocalePacks reads and validates the packs of res/locales which aren't disabled, the English
+// one is required since the others are compared to it
+func ReadLocalePacks() (packs []LocalePack, err error) {
+	entries, err := os.ReadDir(resourcePath(localesDirectory))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the locale packs: %w", err)
+	}
+
+	var english *LocalePack
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pack, err := ReadLocalePack(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if pack.Disabled {
+			continue
+		}
+
+		packs = append(packs, pack)
+		if pack.Tag == "en" {
+			english = &packs[len(packs)-1]
+		}
+	}
+
+	if english == nil {
+		return nil, fmt.Errorf("the locale pack \"en\" is required in %s", localesDirectory)
+	}
+
+	// The genres are translated to the English ones by their index
+	genres := len(english.MoviesGenres)
+	for _, pack := range packs {
+		if len(pack.MoviesGenres) != genres {
+			return nil, fmt.Errorf(
+				"the locale pack %q is incomplete: movies_genres has %d genres instead of %d",
+				pack.Tag, len(pack.MoviesGenres), genres,
+			)
+		}
+	}
+
+	return packs, nil
+}
+
+// ReadLocalePack reads res/locales/<tag>/locale.json and modules.json and checks that the pack
+// has all its files and tables
+func ReadLocalePack(tag string) (pack LocalePack, err error) {
+	directory := resourcePath(filepath.Join(localesDirectory, tag))
+
+	content, err := os.ReadFile(filepath.Join(directory, "locale.json"))
+	if err != nil {
+		return pack, fmt.Errorf("the locale pack %q has no locale.json: %w", tag, err)
+	}
+	if err = json.Unmarshal(content, &pack); err != nil {
+		return pack, fmt.Errorf("the locale.json of the locale pack %q is invalid: %w", tag, err)
+	}
+	if pack.Disabled {
+		return pack, nil
+	}
+
+	var problems []string
+	missing := func(field string) {
+		problems = append(problems, field+" is missing")
+	}
+
+	if pack.Tag != tag {
+		problems = append(problems, fmt.Sprintf("its tag is %q instead of the name of its directory", pack.Tag))
+	}
+	if pack.Name == "" {
+		missing("name")
+	}
+
+	for _, file := range localePackFiles {
+		if _, err := os.Stat(filepath.Join(directory, file)); err != nil {
+			missing(file)
+		}
+	}
+
+	if len(pack.Rules.DaysOfWeek) != 7 {
+		problems = append(problems, fmt.Sprintf("rules.days_of_week has %d days instead of 7", len(pack.Rules.DaysOfWeek)))
+	}
+	if len(pack.Rules.Months) != 12 {
+		problems = append(problems, fmt.Sprintf("rules.months has %d months instead of 12", len(pack.Rules.Months)))
+	}
+
+	for field, value := range map[string]string{
+		"rules.after_tomorrow":   pack.Rules.RuleAfterTomorrow,
+		"rules.next_day_of_week": pack.Rules.RuleNextDayOfWeek,
+		"reason_keywords.that":   pack.ReasonKeywords.That,
+		"reason_keywords.to":     pack.ReasonKeywords.To,
+		"spotify_keywords.play":  pack.SpotifyKeywords.Play,
+		"spotify_keywords.from":  pack.SpotifyKeywords.From,
+		"spotify_keywords.on":    pack.SpotifyKeywords.On,
+	} {
+		if value == "" {
+			missing(field)
+		}
+	}
+
+	// The regular expressions are compiled when a sentence is received, check them now
+	for field, value := range map[string]string{
+		"rules.today":        pack.Rules.RuleToday,
+		"rules.tomorrow":     pack.Rules.RuleTomorrow,
+		"rules.day_of_week":  pack.Rules.RuleDayOfWeek,
+		"rules.natural_date": pack.Rules.RuleNaturalDate,
+		"patterns.date":      pack.Patterns.DateRegex,
+		"patterns.time":      pack.Patterns.TimeRegex,
+		"math_decimals":      pack.MathDecimals,
+	} {
+		if value == "" {
+			missing(field)
+		} else if _, err := regexp.Compile(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not a valid regular expression: %s", field, err))
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(directory, "modules.json")); err == nil {
+		if err = json.Unmarshal(content, &pack.Modules); err != nil {
+			problems = append(problems, fmt.Sprintf("modules.json is invalid: %s", err))
+		}
+	}
+
+	tags := map[string]bool{}
+	for _, module := range pack.Modules {
+		switch {
+		case moduleReplacers[module.Tag] == nil:
+			problems = append(problems, fmt.Sprintf("the module %q has no replacer", module.Tag))
+		case tags[module.Tag]:
+			problems = append(problems, fmt.Sprintf("the module %q is declared twice", module.Tag))
+		case len(module.Patterns) == 0 || len(module.Responses) == 0:
+			problems = append(problems, fmt.Sprintf("the module %q needs patterns and responses", module.Tag))
+		}
+		tags[module.Tag] = true
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return pack, fmt.Errorf("the locale pack %q is incomplete: %s", tag, strings.Join(problems, ", "))
+	}
+
+	return pack, nil
+}
+
+// InstallLocalePack adds the locale of the pack and replaces its tables and modules
+func InstallLocalePack(pack LocalePack) {
+	installLocalePack(pack, newLocaleKeywords(pack))
+}
+
+// installLocalePack is InstallLocalePack with the keywords already indexed, the reloads index them
+// before they lock the resources
+func installLocalePack(pack LocalePack, keywords localeKeywords) {
+	locale := Locale{Tag: pack.Tag, Name: pack.Name}
+	if index := slices.IndexFunc(Locales, func(l Locale) bool { return l.Tag == pack.Tag }); index >= 0 {
+		Locales[index] = locale
+	} else {
+		Locales = append(Locales, locale)
+	}
+
+	localePacks[pack.Tag] = pack
+	LocaleFallbacks[pack.Tag] = pack.Fallbacks
+	RuleTranslations[pack.Tag] = pack.Rules
+	PatternTranslation[pack.Tag] = pack.Patterns
+	ReasonKeywords[pack.Tag] = pack.ReasonKeywords
+	SpotifyKeyword[pack.Tag] = pack.SpotifyKeywords
+	MoviesGenres[pack.Tag] = pack.MoviesGenres
+	MathDecimals[pack.Tag] = pack.MathDecimals
+	keywordIndexes[pack.Tag] = keywords
+
+	articles := pack.CountriesArticles
+	ArticleCountries[pack.Tag] = func(name string) string {
+		if article, exists := articles[name]; exists {
+			return article
+		}
+
+		return name
+	}
+
+	_modules := make([]Modulef, len(pack.Modules))
+	for i, module := range pack.Modules {
+		module.Replacer = moduleReplacers[module.Tag]
+		_modules[i] = module
+	}
+	modulesf[pack.Tag] = _modules
+}
+
+// ReloadLocale reads and validates the resources of the locale again and swaps them with the ones
+// in memory, nothing is replaced when one of the files is invalid. The network is retrained in the
+// background when the patterns changed and RetrainOnReload is enabled.
+func ReloadLocale(locale string, files ...string) error {
+	event := ReloadEvent{Kind: "reload", Locale: locale, Files: files}
+
+	pack, err := ReadLocalePack(locale)
+	if err == nil && pack.Disabled {
+		err = fmt.Errorf("the locale pack %q is disabled, restart the server to remove it", locale)
+	}
+	if err == nil && locale != "en" && len(pack.MoviesGenres) != len(MoviesGenres["en"]) {
+		err = fmt.Errorf(
+			"the locale pack %q is incomplete: movies_genres has %d genres instead of %d",
+			locale, len(pack.MoviesGenres), len(MoviesGenres["en"]),
+		)
+	}
+
+	var messages []DataPacket
+	if err == nil {
+		messages, err = ReadMessages(locale)
+	}
+
+	var _intents []Intent
+	if err == nil {
+		_intents, err = ReadIntents(locale)
+	}
+
+	// The augmentation is read by the next training, check it before it starts
+	if err == nil {
+		_, err = ReadAugmentation(locale)
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+		recordReloadEvent(event)
+		return err
+	}
+
+	// The tables only change with tablesMutex locked, the context is compiled while the replies
+	// still use the previous one and the write lock of the resources is only held for the swaps
+	tablesMutex.Lock()
+	event.PatternsChanged = !reflect.DeepEqual(
+		patternsByTag(GetIntents_l(locale), modulesf[locale]),
+		patternsByTag(_intents, pack.Modules),
+	)
+
+	resourcesMutex.RLock()
+	network, exists := globalNeuralNetworks[locale]
+	resourcesMutex.RUnlock()
+
+	keywords := newLocaleKeywords(pack)
+	resourcesMutex.Lock()
+	cachedDataStore[locale] = messages
+	CacheIntents(locale, _intents)
+	installLocalePack(pack, keywords)
+	resourcesMutex.Unlock()
+
+	// The context keeps the vocabulary of the network and takes the new intents and stopwords
+	var context *NLPContext
+	if exists {
+		context = network.SetContext(_intents)
+	}
+
+	resourcesMutex.Lock()
+	if exists {
+		RegisterNLPContext(context)
+		globalNeuralNetworks[locale] = network
+	} else {
+		nlpContexts.Delete(locale)
+	}
+	languageIdentifier = sync.OnceValue(ReadLanguageIdentifier)
+	FlushCachedPredictions(locale)
+	resourcesMutex.Unlock()
+	tablesMutex.Unlock()
+
+	recordReloadEvent(event)
+
+	if event.PatternsChanged && RetrainOnReload {
+		RetrainInBackground(locale)
+	}
+
+	return nil
+}
+
+// patternsByTag returns the patterns of the intents and of the modules by their tag
+func patternsByTag(_intents []Intent, _modules []Modulef) map[string][]string {
+	patterns := map[string][]string{}
+	for _, intent := range _intents {
+		patterns[intent.Tag] = append(patterns[intent.Tag], intent.Patterns...)
+	}
+	for _, module := range _modules {
+		patterns[module.Tag] = append(patterns[module.Tag], module.Patterns...)
+	}
+
+	return patterns
+}
+
+// RetrainInBackground trains a new network for the locale and swaps it with the current one once
+// it is trained, a retrain asked while another one runs starts when it ends
+func RetrainInBackground(locale string) {
+	retrainMutex.Lock()
+	defer retrainMutex.Unlock()
+
+	if retrainsRunning[locale] {
+		retrainsPending[locale] = true
+		return
+	}
+	retrainsRunning[locale] = true
+
+	go func() {
+		for {
+			retrain(locale)
+
+			retrainMutex.Lock()
+			if !retrainsPending[locale] {
+				retrainsRunning[locale] = false
+				retrainMutex.Unlock()
+				return
+			}
+			retrainsPending[locale] = false
+			retrainMutex.Unlock()
+		}
+	}()
+}
+
+func retrain(locale string) {
+	event := ReloadEvent{Kind: "retrain", Locale: locale}
+
+	// The files may have changed since they were validated, keep the server running anyway
+	defer func() {
+		if err := recover(); err != nil {
+			event.Error = fmt.Sprint(err)
+			recordReloadEvent(event)
+		}
+	}()
+
+	var words, classes []string
+	var inputs, outputs Matrix
+	func() {
+		resourcesMutex.RLock()
+		defer resourcesMutex.RUnlock()
+
+		words, classes, inputs, outputs = trainDataMain(locale)
+	}()
+
+	network := trainAndSaveNetwork(locale, words, classes, inputs, outputs)
+
+	// The intents are read once trained to take the reloads made during the training
+	tablesMutex.Lock()
+	defer tablesMutex.Unlock()
+	context := network.SetContext(GetIntents_l(locale))
+
+	resourcesMutex.Lock()
+	RegisterNLPContext(context)
+	globalNeuralNetworks[locale] = network
+	FlushCachedPredictions(locale)
+	resourcesMutex.Unlock()
+
+	recordReloadEvent(event)
+}
+
+// recordReloadEvent logs the event and keeps it in the history returned by the API
+func recordReloadEvent(event ReloadEvent) {
+	event.Time = time.Now().UTC()
+
+	switch {
+	case event.Error != "":
+		fmt.Printf("%s %s: %s\n", color.FgRed.Render("Could not "+event.Kind), event.Locale, event.Error)
+	case event.Kind == "retrain":
+		fmt.Printf("%s %s\n", color.FgGreen.Render("Retrained the neural network of"), color.FgMagenta.Render(event.Locale))
+	case event.PatternsChanged && !RetrainOnReload:
+		fmt.Printf(
+			"%s %s, %s\n",
+			color.FgGreen.Render("Reloaded"), color.FgMagenta.Render(event.Locale),
+			color.FgYellow.Render("its patterns changed and the neural network needs to be retrained"),
+		)
+	default:
+		fmt.Printf("%s %s\n", color.FgGreen.Render("Reloaded"), color.FgMagenta.Render(event.Locale))
+	}
+
+	reloadEventsMutex.Lock()
+	defer reloadEventsMutex.Unlock()
+
+	reloadEvents = append(reloadEvents, event)
+	if len(reloadEvents) > reloadHistorySize {
+		reloadEvents = reloadEvents[len(reloadEvents)-reloadHistorySize:]
+	}
+}
+
+// GetReloadEvents returns the latest reloads and retrains, the oldest first
+func GetReloadEvents() []ReloadEvent {
+	reloadEventsMutex.Lock()
+	defer reloadEventsMutex.Unlock()
+
+	return slices.Clone(reloadEvents)
+}
+
+// WatchResources checks the files of the locale packs every interval and reloads the locales
+// whose files changed, the locales added after startup need a restart
+func WatchResources(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	states := localeFileStates()
+	for range time.Tick(interval) {
+		current := localeFileStates()
+
+		for locale, files := range current {
+			var changed []string
+			for file, state := range files {
+				if states[locale][file] != state {
+					changed = append(changed, file)
+				}
+			}
+			// The files which were removed are reported too
+			for file := range states[locale] {
+				if _, exists := files[file]; !exists {
+					changed = append(changed, file)
+				}
+			}
+
+			if len(changed) > 0 {
+				sort.Strings(changed)
+				ReloadLocale(locale, changed...)
+			}
+		}
+
+		states = current
+	}
+}
+
+// localeFileStates returns the modification time and size of the files of each locale pack
+func localeFileStates() map[string]map[string]fileState {
+	resourcesMutex.RLock()
+	locales := slices.Clone(Locales)
+	resourcesMutex.RUnlock()
+
+	states := map[string]map[string]fileState{}
+	for _, locale := range locales {
+		states[locale.Tag] = map[string]fileState{}
+
+		directory := resourcePath(filepath.Join(localesDirectory, locale.Tag))
+		for _, file := range append([]string{"locale.json"}, append(localePackFiles, localePackOptionalFiles...)...) {
+			info, err := os.Stat(filepath.Join(directory, file))
+			if err != nil {
+				continue
+			}
+
+			states[locale.Tag][file] = fileState{ModTime: info.ModTime(), Size: info.Size()}
+		}
+	}
+
+	return states
+}
+
+// GetReloads returns the latest reloads of the locale resources
+func GetReloads(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(GetReloadEvents())
+}
+
 func ReplaceContentf(locale, tag, entry, response, token string) (string, string) {
-	for _, module := range modulesf[locale] {
+	for _, module := range GetChainModulesf(locale) {
 		if module.Tag != tag {
 			continue
 		}
@@ -2188,7 +6530,7 @@ func MovieSearchFromInformationReplacer(locale, _, response, token string) (stri
 		return responseTag, SelectRandomMessage(locale, responseTag)
 	}
 
-	movie := SearchMovie(genres[rand.Intn(len(genres))], token)
+	movie := SearchMovie(genres[ResponseRandom(len(genres))], token)
 	genresJoined := strings.Join(genres, ", ")
 	return MoviesDataTag, fmt.Sprintf(response, genresJoined, movie.Name, movie.Rating)
 }
@@ -2214,7 +6556,7 @@ func NameSetterReplacer(locale, entry, response, token string) (string, string)
 	}
 
 	// Capitalize the name
-	name = strings.Title(name)
+	name = cases.Title(language.Make(locale)).String(name)
 
 	// Change the name inside the user information
 	UpdateUserProfile(token, func(information UserProfile) UserProfile {
@@ -2229,7 +6571,7 @@ func RandomNumberReplacer(locale, entry, response, _ string) (string, string) {
 	limitArr, err := FindRangeLimits(locale, entry)
 	if err != nil {
 		if limitArr != nil {
-			return RandomTag, fmt.Sprintf(response, strconv.Itoa(rand.Intn(100)))
+			return RandomTag, fmt.Sprintf(response, strconv.Itoa(ResponseRandom(100)))
 		}
 
 		responseTag := "no random range"
@@ -2238,7 +6580,7 @@ func RandomNumberReplacer(locale, entry, response, _ string) (string, string) {
 
 	min := limitArr[0]
 	max := limitArr[1]
-	randNum := rand.Intn((max - min)) + min
+	randNum := ResponseRandom((max - min)) + min
 	return RandomTag, fmt.Sprintf(response, strconv.Itoa(randNum))
 }
 
@@ -2367,11 +6709,3 @@ func SearchDevice(client spotify.Client, content string) spotify.PlayerDevice {
 
 	return spotify.PlayerDevice{}
 }
-
-func ArticleCountriesOut(name string) string {
-	if name == "United States" {
-		return "the " + name
-	}
-
-	return name
-}
@@ -0,0 +1,1400 @@
+package olivia
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+func TestMain(m *testing.M) {
+	if err := LoadLocalePacks(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestLoadNetworkFullFormat(t *testing.T) {
+	// The model saved by the versions which kept the training matrices and a bias row per sample
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(network.Words) != Rows(network.Weights[0]) || len(network.Classes) != Columns(network.Weights[1]) {
+		t.Fatalf("got %d words and %d classes for weights of %dx%d", len(network.Words), len(network.Classes), Rows(network.Weights[0]), Columns(network.Weights[1]))
+	}
+
+	for i, biases := range network.Biases {
+		if Rows(biases) != 1 {
+			t.Errorf("the biases %d have %d rows, want 1", i, Rows(biases))
+		}
+	}
+
+	// The rebuilt vocabulary must still lead the patterns to their intents
+	pipeline := LegacyPipeline("en")
+	var patterns, correct int
+	for _, intent := range append(SerializeIntents("en"), SerializeModulesIntents("en")...) {
+		for _, pattern := range intent.Patterns {
+			bag := make([]float64, len(network.Words))
+			for _, word := range pipeline.Process(pattern) {
+				if i := SliceIndex(network.Words, word); i >= 0 {
+					bag[i] = 1
+				}
+			}
+
+			patterns++
+			if network.Classes[maxIndex(network.Predict(bag))] == intent.Tag {
+				correct++
+			}
+		}
+	}
+
+	if accuracy := float64(correct) / float64(patterns); accuracy < 0.95 {
+		t.Errorf("the legacy model predicts %d/%d patterns, want at least 95%%", correct, patterns)
+	}
+}
+
+func maxIndex(values []float64) (index int) {
+	for i, value := range values {
+		if value > values[index] {
+			index = i
+		}
+	}
+
+	return
+}
+
+func TestPredictWithoutContext(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The context is only compiled by CreateNeuralNetwork or SetContext
+	if _, err := NewSentence("en", "hello").Predict(*network); !errors.Is(err, ErrNoNLPContext) {
+		t.Fatalf("got %v, want ErrNoNLPContext", err)
+	}
+
+	network.SetContext(SerializeIntents("en"))
+	results, err := NewSentence("en", "hello").Predict(*network)
+	if err != nil || results[0].Tag != "hello" {
+		t.Fatalf("got %v and %v, want the hello intent", results, err)
+	}
+}
+
+func TestCalculateCacheByLocale(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a context the network of the other locale can't predict anything
+	other := *network
+	network.SetContext(SerializeIntents("en"))
+
+	cache := gocache.New(time.Minute, time.Minute)
+	if tag, _, _, _ := (Sentence{Locale: "en", Content: "hello"}).Calculate(*cache, *network, "token"); tag != "hello" {
+		t.Fatalf("got the tag %q, want hello", tag)
+	}
+
+	tag, _, results, _ := (Sentence{Locale: "xx", Content: "hello"}).Calculate(*cache, other, "token")
+	if tag != DontUnderstand || len(results) != 0 {
+		t.Errorf("got the tag %q and %v from the cache of the other locale", tag, results)
+	}
+}
+
+func TestReloadFlushesCachedPredictions(t *testing.T) {
+	cacheInstance.Set("en:hello", []Result{{Tag: "goodbye", Value: 1}}, gocache.DefaultExpiration)
+	cacheInstance.Set("de:hello", []Result{{Tag: "goodbye", Value: 1}}, gocache.DefaultExpiration)
+	defer cacheInstance.Flush()
+
+	if err := ReloadLocale("en"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the results of the reloaded locale are predicted again
+	if _, found := cacheInstance.Get("en:hello"); found {
+		t.Error("the results of the reloaded locale are still cached")
+	}
+	if _, found := cacheInstance.Get("de:hello"); !found {
+		t.Error("the results of the other locale aren't cached anymore")
+	}
+}
+
+func TestReplyReleasesResourcesBeforeModules(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := GetNLPContext("en")
+	defer RegisterNLPContext(context)
+	RegisterNLPContext(network.SetContext(SerializeIntents("en")))
+
+	networks := globalNeuralNetworks
+	defer func() { globalNeuralNetworks = networks }()
+	globalNeuralNetworks = map[string]Network{"en": *network}
+
+	// A reload can swap the resources while the module waits for its web service
+	var locked bool
+	_modules := modulesf["en"]
+	defer func() { modulesf["en"] = _modules }()
+	modulesf["en"] = append(slices.Clone(_modules), Modulef{
+		Tag: "hello",
+		Replacer: func(locale, entry, response, token string) (string, string) {
+			if locked = resourcesMutex.TryLock(); locked {
+				resourcesMutex.Unlock()
+			}
+
+			return "hello", response
+		},
+	})
+	defer cacheInstance.Flush()
+
+	generateReply(clientRequestMessage{Type: 1, Content: "hello", Token: "token", Locale: "en"})
+	if !locked {
+		t.Error("the resources are still locked while the module replaces the response")
+	}
+}
+
+func TestRandomizeResponseWithoutResponses(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := GetNLPContext("en")
+	defer RegisterNLPContext(context)
+	RegisterNLPContext(network.SetContext([]Intent{{Tag: "hello", Patterns: []string{"hello"}}}))
+
+	if tag, _, _ := RandomizeResponse("en", "hello", "hello", "token"); tag != DontUnderstand {
+		t.Errorf("got the tag %q for an intent without responses, want %q", tag, DontUnderstand)
+	}
+}
+
+func TestDisabledLocalePacks(t *testing.T) {
+	packs, err := ReadLocalePacks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The translations of the old locales are kept in their packs until they are complete
+	for _, tag := range []string{"de", "fr", "es", "ca", "it", "tr", "nl", "el"} {
+		pack, err := ReadLocalePack(tag)
+		if err != nil {
+			t.Errorf("cannot read the pack %q: %s", tag, err)
+			continue
+		}
+
+		if !pack.Disabled || pack.Tag != tag || pack.ReasonKeywords.That == "" || pack.MathDecimals == "" {
+			t.Errorf("the pack %q is %+v, want a disabled pack with its tables", tag, pack)
+		}
+
+		for _, installed := range packs {
+			if installed.Tag == tag {
+				t.Errorf("the disabled pack %q is installed", tag)
+			}
+		}
+	}
+}
+
+// tokenizerSentences are sentences of the locales whose packs are disabled, with their tokens
+var tokenizerSentences = map[string]struct {
+	sentence string
+	tokens   []string
+}{
+	"de": {"Wie spät ist es in Köln?", []string{"Wie", "spät", "ist", "es", "in", "Köln"}},
+	"fr": {"Qu'est-ce que c'est, l'heure à Paris ?", []string{"Qu'est", "ce", "que", "c'est", "l'heure", "à", "Paris"}},
+	"es": {"¿Qué tiempo hace en España?", []string{"Qué", "tiempo", "hace", "en", "España"}},
+	"ca": {"On és el col·legi d'en Jordi?", []string{"On", "és", "el", "col·legi", "d'en", "Jordi"}},
+	"it": {"Com'è il tempo a Roma?", []string{"Com'è", "il", "tempo", "a", "Roma"}},
+	"tr": {"İstanbul'da hava nasıl?", []string{"İstanbul'da", "hava", "nasıl"}},
+	"nl": {"Hoe laat is het in 's-Hertogenbosch?", []string{"Hoe", "laat", "is", "het", "in", "s", "Hertogenbosch"}},
+	// The accent of "ώρα" is a combining mark which belongs to its letter
+	"el": {"Τι ω\u0301ρα είναι στην Αθήνα;", []string{"Τι", "ω\u0301ρα", "είναι", "στην", "Αθήνα"}},
+}
+
+func TestUnicodeTokenizer(t *testing.T) {
+	// Their words are separated, the segmenter of a CJK locale must not change them
+	segmenter := ReadDictionarySegmenter("zh")
+	if segmenter == nil {
+		t.Fatal("the zh locale has no dictionary")
+	}
+
+	for locale, test := range tokenizerSentences {
+		if ReadDictionarySegmenter(locale) != nil {
+			t.Errorf("the %s locale has a dictionary", locale)
+		}
+
+		for _, tokenizer := range []UnicodeTokenizer{{}, {Segmenter: segmenter}} {
+			if tokens := tokenizer.Tokenize(test.sentence); !reflect.DeepEqual(tokens, test.tokens) {
+				t.Errorf("%s: got %q, want %q", locale, tokens, test.tokens)
+			}
+		}
+	}
+}
+
+func TestDictionarySegmenter(t *testing.T) {
+	tokenizer := UnicodeTokenizer{Segmenter: ReadDictionarySegmenter("zh")}
+
+	for sentence, tokens := range map[string][]string{
+		"你好，今天天气怎么样？":    {"你好", "今天", "天气", "怎么样"},
+		"我想听Daft Punk的歌": {"我", "想", "听", "Daft", "Punk", "的", "歌"},
+		"推荐一个科幻电影":       {"推荐", "一个", "科幻", "电影"},
+		"法国的首都是巴黎吗":      {"法国", "的", "首都", "是", "巴黎", "吗"},
+		// The unknown characters are words on their own
+		"我叫小明": {"我", "叫", "小", "明"},
+	} {
+		if got := tokenizer.Tokenize(sentence); !reflect.DeepEqual(got, tokens) {
+			t.Errorf("%s: got %q, want %q", sentence, got, tokens)
+		}
+	}
+
+	// Without a dictionary the characters are split one by one
+	if got := (UnicodeTokenizer{}).Tokenize("你好"); !reflect.DeepEqual(got, []string{"你", "好"}) {
+		t.Errorf("got %q without a segmenter", got)
+	}
+}
+
+func TestJaroWinklerSimilarity(t *testing.T) {
+	for _, test := range []struct {
+		first, second string
+		similarity    float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.813},
+		// The odd number of transpositions counts for a half
+		{"martha", "mrtaha", 0.925},
+		{"été", "été", 1},
+		{"abc", "xyz", 0},
+	} {
+		if similarity := JaroWinklerSimilarity(test.first, test.second); math.Abs(similarity-test.similarity) > 0.001 {
+			t.Errorf("%s and %s: got %f, want %f", test.first, test.second, similarity, test.similarity)
+		}
+	}
+}
+
+// recursiveLevenshteinDistance is the byte based recursion which LevenshteinDistance replaced
+func recursiveLevenshteinDistance(first, second string) int {
+	if first == "" {
+		return len(second)
+	}
+	if second == "" {
+		return len(first)
+	}
+
+	if first[0] == second[0] {
+		return recursiveLevenshteinDistance(first[1:], second[1:])
+	}
+
+	return min(
+		recursiveLevenshteinDistance(first[1:], second[1:]),
+		recursiveLevenshteinDistance(first, second[1:]),
+		recursiveLevenshteinDistance(first[1:], second),
+	) + 1
+}
+
+// recursiveFindMoviesGenres finds the genres like FindMoviesGenres did with the recursion
+func recursiveFindMoviesGenres(locale, content string) (output []string) {
+	for i, genre := range MoviesGenres[locale] {
+		for _, word := range strings.Split(strings.ToUpper(content), " ") {
+			if recursiveLevenshteinDistance(word, strings.ToUpper(genre)) <= 2 {
+				output = append(output, MoviesGenres["en"][i])
+				break
+			}
+		}
+	}
+
+	return
+}
+
+var keywordSentences = []string{
+	"I would like to watch a comedy or an horor movie",
+	"give me a good documentry",
+	"play Billie Jean from Michael Jackson on Spotify",
+	"plai Thriller fron Michael Jackson",
+	"remind me that I have to buy some milk",
+	"remind me to call my mother",
+}
+
+func TestKeywordIndexes(t *testing.T) {
+	for _, sentence := range keywordSentences {
+		if got, want := FindMoviesGenres("en", sentence), recursiveFindMoviesGenres("en", sentence); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got the genres %q, want %q", sentence, got, want)
+		}
+	}
+
+	for sentence, want := range map[string][2]string{
+		"play Billie Jean from Michael Jackson on Spotify": {"Billie Jean", "Michael Jackson"},
+		"plai Thriller fron Michael Jackson":               {"Thriller", "Michael Jackson"},
+	} {
+		if music, artist := SearchMusic("en", sentence); music != want[0] || artist != want[1] {
+			t.Errorf("%s: got %q from %q, want %q", sentence, music, artist, want)
+		}
+	}
+
+	for sentence, want := range map[string]string{
+		"remind me that I have to buy some milk": "I have to buy some milk",
+		"remind me to call my mother":            "call my mother",
+	} {
+		if reason := SearchReason("en", sentence); reason != want {
+			t.Errorf("%s: got %q, want %q", sentence, reason, want)
+		}
+	}
+
+	// The locales without a pack have no keywords
+	if genres := FindMoviesGenres("xx", "comedy"); genres != nil {
+		t.Errorf("got %q for an unknown locale", genres)
+	}
+}
+
+func BenchmarkLevenshteinDistance(b *testing.B) {
+	words := [][2]string{{"documentary", "documentry"}, {"thriller", "michael"}, {"from", "spotify"}}
+
+	b.Run("iterative", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, pair := range words {
+				LevenshteinDistance(pair[0], pair[1])
+			}
+		}
+	})
+
+	b.Run("recursive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, pair := range words {
+				recursiveLevenshteinDistance(pair[0], pair[1])
+			}
+		}
+	})
+}
+
+func BenchmarkFindMoviesGenres(b *testing.B) {
+	sentence := strings.Join(keywordSentences[:2], " ")
+
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FindMoviesGenres("en", sentence)
+		}
+	})
+
+	b.Run("recursive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			recursiveFindMoviesGenres("en", sentence)
+		}
+	})
+}
+
+func TestMergeTranslatedListKeepsPositions(t *testing.T) {
+	sources := []string{"hello", "hi", "hey", "good morning"}
+
+	merged := mergeTranslatedList("p", sources, []string{"bonjour"}, map[string]string{"p/2": "salut"})
+	if want := []string{"bonjour", "", "salut"}; !reflect.DeepEqual(merged, want) {
+		t.Fatalf("got %q, want %q", merged, want)
+	}
+
+	// The keys of the units still lead to the same English texts
+	for _, unit := range appendTranslationUnits(nil, "p", sources, merged) {
+		want := map[string]string{"p/0": "bonjour", "p/2": "salut"}[unit.Key]
+		if unit.Target != want {
+			t.Errorf("the unit %s has the target %q, want %q", unit.Key, unit.Target, want)
+		}
+	}
+
+	merged = mergeTranslatedList("p", sources, merged, map[string]string{"p/1": "coucou", "p/3": "bon matin"})
+	if want := []string{"bonjour", "coucou", "salut", "bon matin"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("got %q, want %q", merged, want)
+	}
+
+	// The texts of the locale after the English ones are kept
+	merged = mergeTranslatedList("p", sources[:1], []string{"", "bonsoir"}, map[string]string{})
+	if want := []string{"", "bonsoir"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("got %q, want %q", merged, want)
+	}
+}
+
+func TestParseAugmentation(t *testing.T) {
+	if _, err := ParseAugmentation([]byte(`{"synonyms": "hello"}`)); err == nil {
+		t.Error("got no error for invalid tables")
+	}
+
+	augmentation, err := ParseAugmentation([]byte(`{"synonyms": [["hi", "Hello"]], "keyboard": ["qwe"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(augmentation.synonyms["hello"], []string{"hi", "Hello"}) ||
+		!reflect.DeepEqual(augmentation.neighbors['w'], []rune{'q', 'e'}) {
+		t.Errorf("got the synonyms %v and the neighbors %v", augmentation.synonyms, augmentation.neighbors)
+	}
+
+	// The locales without tables are augmented with word dropouts and swaps
+	if _, err := ReadAugmentation("xx"); err != nil {
+		t.Errorf("got %s for a locale without augmentation.json", err)
+	}
+}
+
+// sliceDotProduct and sliceTranspose are the products of the matrices made of slices of rows which
+// the flat matrices replaced
+func sliceDotProduct(matrix, matrix2 [][]float64) [][]float64 {
+	resultMatrix := make([][]float64, len(matrix))
+	for i := range matrix {
+		resultMatrix[i] = make([]float64, len(matrix2[0]))
+		for j := range resultMatrix[i] {
+			var sum float64
+			for k := range matrix2 {
+				sum += matrix[i][k] * matrix2[k][j]
+			}
+
+			resultMatrix[i][j] = sum
+		}
+	}
+
+	return resultMatrix
+}
+
+func sliceTranspose(matrix [][]float64) [][]float64 {
+	resultMatrix := make([][]float64, len(matrix[0]))
+	for i := range resultMatrix {
+		resultMatrix[i] = make([]float64, len(matrix))
+		for j := range matrix {
+			resultMatrix[i][j] = matrix[j][i]
+		}
+	}
+
+	return resultMatrix
+}
+
+// sparseMatrix draws a matrix whose values are zeros for the given part of them, like the bags of
+// words
+func sparseMatrix(rows, columns int, zeros float64, random *rand.Rand) Matrix {
+	matrix := RandomMatrix(rows, columns, 1, random)
+	for k := range matrix.data {
+		if random.Float64() < zeros {
+			matrix.data[k] = 0
+		}
+	}
+
+	return matrix
+}
+
+func assertSameValues(t *testing.T, name string, got Matrix, want [][]float64) {
+	t.Helper()
+
+	if Rows(got) != len(want) || Columns(got) != len(want[0]) {
+		t.Fatalf("%s: got a %dx%d matrix, want %dx%d", name, Rows(got), Columns(got), len(want), len(want[0]))
+	}
+
+	for i, row := range want {
+		for j, value := range row {
+			x := got.Row(i)[j]
+			if x != value && !(math.IsNaN(x) && math.IsNaN(value)) {
+				t.Fatalf("%s: got %v at %d,%d, want %v", name, x, i, j, value)
+			}
+		}
+	}
+}
+
+func TestDotProducts(t *testing.T) {
+	// Split the rows between goroutines even on a single processor
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	random := rand.New(rand.NewSource(1))
+	shapes := [][3]int{
+		{1, 1, 1},
+		{3, 5, 2},
+		{91, 122, 50},
+		{dotProductBlockSize + 3, 2*dotProductBlockSize + 1, 7},
+		// Large enough to be split between the goroutines
+		{200, 300, 150},
+	}
+
+	for _, shape := range shapes {
+		matrix := sparseMatrix(shape[0], shape[1], 0.8, random)
+		matrix2 := RandomMatrix(shape[1], shape[2], 1, random)
+		slices, slices2 := matrix.Slices(), matrix2.Slices()
+
+		want := sliceDotProduct(slices, slices2)
+		assertSameValues(t, "DotProduct", DotProduct(matrix, matrix2), want)
+
+		// The transposes are the ones of the products above
+		assertSameValues(t, "TransposedDotProduct", TransposedDotProduct(Transpose(matrix), matrix2), want)
+		assertSameValues(t, "DotProductTransposed", DotProductTransposed(matrix, Transpose(matrix2)), want)
+		assertSameValues(
+			t, "TransposedDotProduct",
+			TransposedDotProduct(matrix, DotProduct(matrix, matrix2)),
+			sliceDotProduct(sliceTranspose(slices), want),
+		)
+	}
+}
+
+func TestDotProductsPropagateNaN(t *testing.T) {
+	// The zeros of the first matrix multiply the infinites and the NaNs of the second one
+	matrix := NewMatrix([][]float64{{0, 1}, {0, 0}})
+	matrix2 := NewMatrix([][]float64{{math.Inf(1), 2}, {3, math.NaN()}})
+	want := sliceDotProduct(matrix.Slices(), matrix2.Slices())
+
+	assertSameValues(t, "DotProduct", DotProduct(matrix, matrix2), want)
+	assertSameValues(t, "TransposedDotProduct", TransposedDotProduct(Transpose(matrix), matrix2), want)
+	assertSameValues(t, "DotProductTransposed", DotProductTransposed(matrix, Transpose(matrix2)), want)
+
+	for _, value := range DotProduct(matrix, matrix2).data {
+		if !math.IsNaN(value) {
+			t.Fatalf("got %v, want NaN for each value", value)
+		}
+	}
+}
+
+func benchmarkDotProduct(b *testing.B, rows, columns, columns2 int) {
+	random := rand.New(rand.NewSource(1))
+	matrix := sparseMatrix(rows, columns, 0.9, random)
+	matrix2 := RandomMatrix(columns, columns2, 1, random)
+
+	b.Run("flat", func(b *testing.B) {
+		var result Matrix
+		for i := 0; i < b.N; i++ {
+			DotProductInto(&result, matrix, matrix2)
+		}
+	})
+
+	b.Run("slices", func(b *testing.B) {
+		slices, slices2 := matrix.Slices(), matrix2.Slices()
+		for i := 0; i < b.N; i++ {
+			sliceDotProduct(slices, slices2)
+		}
+	})
+}
+
+// The product of the bags of words of the English intents by the first layer of weights
+func BenchmarkDotProductBags(b *testing.B) {
+	benchmarkDotProduct(b, 91, 122, 50)
+}
+
+func BenchmarkDotProductLarge(b *testing.B) {
+	benchmarkDotProduct(b, 512, 512, 512)
+}
+
+// matrices are random operands for the properties of the Into operations, matrix2 has the shape
+// of matrix and row is a single row with its columns
+type matrices struct {
+	matrix, matrix2, row, other Matrix
+}
+
+func (matrices) Generate(random *rand.Rand, size int) reflect.Value {
+	rows, columns := 1+random.Intn(8), 1+random.Intn(8)
+
+	return reflect.ValueOf(matrices{
+		matrix:  RandomMatrix(rows, columns, 1, random),
+		matrix2: RandomMatrix(rows, columns, 1, random),
+		row:     RandomMatrix(1, columns, 1, random),
+		// A matrix of another shape whose buffer is reused by the results
+		other: RandomMatrix(1+random.Intn(8), 1+random.Intn(8), 1, random),
+	})
+}
+
+func sameMatrix(matrix, matrix2 Matrix) bool {
+	return Rows(matrix) == Rows(matrix2) && Columns(matrix) == Columns(matrix2) && reflect.DeepEqual(matrix.data, matrix2.data)
+}
+
+func TestIntoMatchesNewMatrices(t *testing.T) {
+	property := func(m matrices) bool {
+		result := CopyMatrix(m.other)
+		if SumInto(&result, m.matrix, m.matrix2) != nil || !sameMatrix(result, Sum(m.matrix, m.matrix2)) {
+			return false
+		}
+
+		result = CopyMatrix(m.other)
+		if DifferenceInto(&result, m.matrix, m.matrix2) != nil || !sameMatrix(result, Difference(m.matrix, m.matrix2)) {
+			return false
+		}
+
+		result = CopyMatrix(m.other)
+		if MultiplicationInto(&result, m.matrix, m.matrix2) != nil || !sameMatrix(result, Multiplication(m.matrix, m.matrix2)) {
+			return false
+		}
+
+		result = CopyMatrix(m.other)
+		if TransposeInto(&result, m.matrix) != nil || !sameMatrix(result, Transpose(m.matrix)) {
+			return false
+		}
+
+		result = CopyMatrix(m.other)
+		if DotProductInto(&result, m.matrix, Transpose(m.matrix2)) != nil || !sameMatrix(result, DotProduct(m.matrix, Transpose(m.matrix2))) {
+			return false
+		}
+
+		result = CopyMatrix(m.other)
+		ApplyFunctionInto(&result, m.matrix, math.Abs)
+		return sameMatrix(result, ApplyFunction(CopyMatrix(m.matrix), math.Abs))
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntoBroadcastsRows(t *testing.T) {
+	property := func(m matrices) bool {
+		repeated := RepeatRow(m.row.Row(0), Rows(m.matrix))
+
+		var result Matrix
+		if SumInto(&result, m.matrix, m.row) != nil || !sameMatrix(result, Sum(m.matrix, repeated)) {
+			return false
+		}
+
+		if MultiplicationInto(&result, m.matrix, m.row) != nil || !sameMatrix(result, Multiplication(m.matrix, repeated)) {
+			return false
+		}
+
+		// The matrix can be its own result when the row is broadcast
+		result = CopyMatrix(m.matrix)
+		return DifferenceInto(&result, result, m.row) == nil && sameMatrix(result, Difference(m.matrix, repeated))
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntoShapeErrors(t *testing.T) {
+	property := func(m matrices) bool {
+		wider := RandomMatrix(Rows(m.matrix), Columns(m.matrix)+1, 1, rand.New(rand.NewSource(1)))
+		taller := RandomMatrix(Rows(m.matrix)+1, Columns(m.matrix), 1, rand.New(rand.NewSource(1)))
+
+		result := CopyMatrix(m.other)
+		errs := []error{
+			SumInto(&result, m.matrix, wider),
+			DifferenceInto(&result, m.matrix, taller),
+			MultiplicationInto(&result, m.matrix, taller),
+			DotProductInto(&result, m.matrix, Transpose(wider)),
+			TransposedDotProductInto(&result, m.matrix, taller),
+			DotProductTransposedInto(&result, m.matrix, wider),
+		}
+
+		for _, err := range errs {
+			if !errors.Is(err, ErrMatrixShape) {
+				return false
+			}
+		}
+
+		// The result is left untouched
+		return sameMatrix(result, m.other)
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIntoAliasingErrors(t *testing.T) {
+	property := func(m matrices) bool {
+		square := CopyMatrix(m.matrix)
+		square.resize(Rows(m.matrix), Rows(m.matrix))
+		row := CopyMatrix(m.row)
+
+		errs := []error{
+			DotProductInto(&square, square, square),
+			TransposedDotProductInto(&square, square, square),
+			DotProductTransposedInto(&square, square, square),
+			TransposeInto(&square, square),
+			// A broadcast row which is also the result
+			SumInto(&row, RepeatRow(m.row.Row(0), 2), row),
+		}
+
+		for _, err := range errs {
+			if !errors.Is(err, ErrMatrixAliasing) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// gradientNetwork is a small network with one-hot outputs whose output layer uses the given
+// activation and loss
+func gradientNetwork(t *testing.T, activation, loss string) Network {
+	random := rand.New(rand.NewSource(1))
+	outputs := CreateMatrix(6, 3)
+	for i := 0; i < Rows(outputs); i++ {
+		outputs.Set(i, i%3, 1)
+	}
+
+	network := CreateNetwork("en", 0.1, random, RandomMatrix(6, 4, 1, random), outputs, HiddenLayer{Nodes: 5, Activation: TanhActivation})
+	if err := network.SetOutputActivation(activation, loss); err != nil {
+		t.Fatal(err)
+	}
+
+	return network
+}
+
+// checkGradients compares the adjustments of the backward pass, which are the opposite of the
+// gradient of the loss, with the finite differences of ComputeLoss. The loss is averaged by sample
+// while the adjustments are summed over the training samples.
+func checkGradients(t *testing.T, network Network) {
+	network.FeedForward()
+	derivatives := make([]LayerDerivative, len(network.Weights))
+	if err := network.CalculateFinalLayerDerivatives(&derivatives[0]); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(network.Layers)-2; i++ {
+		if err := network.CalculateLayerDerivatives(i, derivatives); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const epsilon = 1e-6
+	for i, derivative := range derivatives {
+		l := len(derivatives) - 1 - i
+
+		for _, parameter := range []struct {
+			name             string
+			values, adjusted Matrix
+		}{
+			{"weights", network.Weights[l], derivative.Adjustment},
+			{"biases", network.Biases[l], derivative.BiasAdjustment},
+		} {
+			for k, value := range parameter.values.data {
+				parameter.values.data[k] = value + epsilon
+				loss := network.ComputeLoss(network.Forward(network.Layers[0]), network.Output)
+				parameter.values.data[k] = value - epsilon
+				loss -= network.ComputeLoss(network.Forward(network.Layers[0]), network.Output)
+				parameter.values.data[k] = value
+
+				gradient := loss / (2 * epsilon) * float64(max(network.samples, Rows(network.Output)))
+				if math.Abs(parameter.adjusted.data[k]+gradient) > 1e-6 {
+					t.Fatalf("%s %d of layer %d: adjustment %g, gradient %g", parameter.name, k, l, parameter.adjusted.data[k], gradient)
+				}
+			}
+		}
+	}
+}
+
+func TestOutputGradients(t *testing.T) {
+	for _, activation := range []struct{ activation, loss string }{
+		{SoftmaxActivation, CrossEntropyLoss},
+		{SigmoidActivation, BinaryCrossEntropyLoss},
+	} {
+		t.Run(activation.loss, func(t *testing.T) {
+			checkGradients(t, gradientNetwork(t, activation.activation, activation.loss))
+		})
+
+		// The 6 samples are a batch of a training set of 18 samples
+		t.Run(activation.loss+" batch", func(t *testing.T) {
+			network := gradientNetwork(t, activation.activation, activation.loss)
+			network.samples = 18
+			checkGradients(t, network)
+		})
+	}
+}
+
+func TestOptimizers(t *testing.T) {
+	for _, test := range []struct {
+		optimizer string
+		// want is the parameter after each adjustment, starting from 1 with a rate of 0.1
+		want []float64
+	}{
+		{SGDOptimizer, []float64{1.2, 1.1}},
+		// The velocity keeps 0.9 of the previous step
+		{MomentumOptimizer, []float64{1.2, 1.28}},
+		// The first step of Adam is the rate in the direction of the adjustment
+		{AdamOptimizer, []float64{1.0999999995, 1.1266337032975686}},
+	} {
+		optimizer, err := NewOptimizer(test.optimizer, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parameter := NewMatrix([][]float64{{1}})
+		for i, adjustment := range []float64{2, -1} {
+			optimizer.Update([]Matrix{parameter}, []Matrix{NewMatrix([][]float64{{adjustment}})})
+
+			if got := parameter.At(0, 0); math.Abs(got-test.want[i]) > 1e-12 {
+				t.Errorf("%s: parameter %g after step %d, want %g", test.optimizer, got, i+1, test.want[i])
+			}
+		}
+	}
+
+	if _, err := NewOptimizer("rmsprop", 0.1); err == nil {
+		t.Error("NewOptimizer accepted an unknown optimizer")
+	}
+}
+
+func TestOutputLoss(t *testing.T) {
+	for _, test := range []struct {
+		activation, loss, want string
+	}{
+		{SigmoidActivation, "", MeanSquaredLoss},
+		{SigmoidActivation, BinaryCrossEntropyLoss, BinaryCrossEntropyLoss},
+		{SoftmaxActivation, "", CrossEntropyLoss},
+		{SoftmaxActivation, MeanSquaredLoss, ""},
+		{SigmoidActivation, CrossEntropyLoss, ""},
+		{SigmoidActivation, "hinge", ""},
+	} {
+		loss, err := OutputLoss(test.activation, test.loss)
+		if loss != test.want || (err == nil) != (test.want != "") {
+			t.Errorf("OutputLoss(%q, %q) = %q, %v, want %q", test.activation, test.loss, loss, err, test.want)
+		}
+	}
+}
+
+func TestBinaryNetwork(t *testing.T) {
+	network := gradientNetwork(t, SoftmaxActivation, "")
+	network.Words, network.Classes = []string{"a", "b", "c", "d"}, []string{"x", "y", "z"}
+	network.Version = ModelFormatVersion
+	network.CreatedAt = time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	network.DataHash = "hash"
+	network.Layers, network.Output = nil, Matrix{}
+
+	content, err := encodeBinaryNetwork(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// setVersion writes the version which follows the magic
+	setVersion := func(version uint16) func([]byte) []byte {
+		return func(content []byte) []byte {
+			content[len(modelMagic)], content[len(modelMagic)+1] = byte(version), byte(version>>8)
+			return content
+		}
+	}
+
+	for _, test := range []struct {
+		name   string
+		modify func([]byte) []byte
+		err    error
+	}{
+		{"round trip", func(content []byte) []byte { return content }, nil},
+		{"bad magic", func(content []byte) []byte { content[0] = 'X'; return content }, ErrModelFormat},
+		{"corrupted payload", func(content []byte) []byte { content[len(content)/2] ^= 0xff; return content }, ErrModelChecksum},
+		{"corrupted checksum", func(content []byte) []byte { content[len(content)-1] ^= 0xff; return content }, ErrModelChecksum},
+		{"truncated", func(content []byte) []byte { return content[:len(content)-10] }, ErrModelChecksum},
+		{"header only", func(content []byte) []byte { return content[:len(modelMagic)+1] }, ErrModelFormat},
+		{"version too new", setVersion(ModelFormatVersion + 1), ErrModelVersion},
+		{"version too old", setVersion(minimumBinaryModelVersion - 1), ErrModelVersion},
+		{"version 0", setVersion(0), ErrModelVersion},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := t.TempDir() + "/training.olvm"
+			if err := os.WriteFile(fileName, test.modify(append([]byte(nil), content...)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := LoadNetwork(fileName)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("got the error %v, want %v", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded.Weights, network.Weights) || !reflect.DeepEqual(loaded.Biases, network.Biases) {
+				t.Error("the weights changed")
+			}
+			if loaded.Locale != network.Locale || !loaded.CreatedAt.Equal(network.CreatedAt) || loaded.DataHash != network.DataHash ||
+				loaded.GetLoss() != CrossEntropyLoss || !reflect.DeepEqual(loaded.Words, network.Words) {
+				t.Errorf("the header or the fields changed: %+v", loaded)
+			}
+		})
+	}
+
+	// Every flipped byte after the magic is reported, the decoding never panics
+	for i := len(modelMagic); i < len(content); i++ {
+		modified := append([]byte(nil), content...)
+		modified[i] ^= 0x55
+
+		var loaded Network
+		if err := decodeBinaryNetwork(modified, &loaded); !errors.Is(err, ErrModelFormat) && !errors.Is(err, ErrModelVersion) && !errors.Is(err, ErrModelChecksum) {
+			t.Errorf("byte %d: got the error %v", i, err)
+		}
+	}
+}
+
+func TestTrainNetworkSeed(t *testing.T) {
+	network := gradientNetwork(t, SigmoidActivation, "")
+	config := TrainingConfig{
+		HiddenLayers:     []HiddenLayer{{Nodes: 5, Activation: SigmoidActivation}},
+		OutputActivation: SigmoidActivation,
+		Rate:             0.1,
+		Optimizer:        AdamOptimizer,
+		BatchSize:        2,
+		Shuffle:          true,
+		Epochs:           20,
+		Dropout:          0.2,
+		Seed:             42,
+	}
+
+	first := TrainNetwork("en", config, network.Layers[0], network.Output)
+	second := TrainNetwork("en", config, network.Layers[0], network.Output)
+
+	for i := range first.Weights {
+		for _, matrices := range [][2]Matrix{{first.Weights[i], second.Weights[i]}, {first.Biases[i], second.Biases[i]}} {
+			for k, value := range matrices[0].data {
+				if math.Float64bits(value) != math.Float64bits(matrices[1].data[k]) {
+					t.Fatalf("the parameter %d of the layer %d differs: %v and %v", k, i, value, matrices[1].data[k])
+				}
+			}
+		}
+	}
+}
+
+func TestRandomizeResponseWithStubbedRandom(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := GetNLPContext("en")
+	defer RegisterNLPContext(context)
+	RegisterNLPContext(network.SetContext([]Intent{
+		{Tag: "hello", Patterns: []string{"hello"}, Responses: []string{"Hi", "Hello", "Hey"}},
+	}))
+
+	defer func(random func(int) int) { ResponseRandom = random }(ResponseRandom)
+	for i, want := range []string{"Hi", "Hello", "Hey"} {
+		ResponseRandom = func(n int) int { return i }
+
+		if _, response, _ := RandomizeResponse("en", "hello", "hello", "token"); response != want {
+			t.Errorf("got the response %q for the index %d, want %q", response, i, want)
+		}
+	}
+}
+
+func TestReadStopWordsWithoutFile(t *testing.T) {
+	if stopWords := ReadStopWords("xx"); len(stopWords) != 0 {
+		t.Errorf("got the stopwords %v for a locale without file", stopWords)
+	}
+
+	if tokens := DefaultPipeline("xx").Process("what is the time in the city"); len(tokens) == 0 {
+		t.Error("the pipeline of a locale without stopwords removed all the words")
+	}
+
+	if len(ReadStopWords("en")) == 0 {
+		t.Error("the English stopwords weren't read")
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	defer func(locales []Locale, identifier func() *LanguageIdentifier, threshold float64) {
+		Locales, languageIdentifier, LanguageOverrideThreshold = locales, identifier, threshold
+	}(Locales, languageIdentifier, LanguageOverrideThreshold)
+
+	identifier := NewLanguageIdentifier(map[string][]string{
+		"aa": {"the cat is on the table", "where is the weather going", "what time is it"},
+		"bb": {"der hund ist unter dem tisch", "wie ist das wetter", "wie spät ist es"},
+	})
+	languageIdentifier = func() *LanguageIdentifier { return identifier }
+	Locales = []Locale{{Tag: "aa"}, {Tag: "bb"}}
+	LanguageOverrideThreshold = 0.9
+
+	for _, test := range []struct {
+		requested, content, want, detected string
+	}{
+		{"", "what is the weather", "aa", "aa"},
+		{"", "wie ist das wetter", "bb", "bb"},
+		// The requested locale is replaced by the more likely one
+		{"bb", "where is the cat", "aa", "aa"},
+		{"aa", "where is the cat", "aa", "aa"},
+		// Nothing is detected without letters
+		{"bb", "42", "bb", ""},
+		{"zz", "42", DefaultLocale, ""},
+	} {
+		locale, detected := DetectLocale(test.requested, test.content)
+
+		detectedTag := ""
+		if detected != nil {
+			detectedTag = detected.Tag
+		}
+		if locale != test.want || detectedTag != test.detected {
+			t.Errorf("DetectLocale(%q, %q) = %q, %q, want %q, %q", test.requested, test.content, locale, detectedTag, test.want, test.detected)
+		}
+	}
+
+	// A single locale is kept without detecting the language
+	Locales = []Locale{{Tag: "bb"}}
+	if locale, detected := DetectLocale("bb", "where is the cat"); locale != "bb" || detected != nil {
+		t.Errorf("got %q and %v with a single locale", locale, detected)
+	}
+}
+
+func TestReadLanguageIdentifierKeepsIntents(t *testing.T) {
+	defer CacheIntents("en", GetIntents_l("en"))
+	cached := []Intent{{Tag: "cached", Patterns: []string{"cached"}, Responses: []string{"cached"}}}
+	CacheIntents("en", cached)
+
+	if identifier := ReadLanguageIdentifier(); len(identifier.profiles) == 0 {
+		t.Error("the identifier has no profiles")
+	}
+	if !reflect.DeepEqual(GetIntents_l("en"), cached) {
+		t.Error("reading the identifier replaced the intents of the locale")
+	}
+}
+
+func TestConfidenceThresholds(t *testing.T) {
+	words, classes, inputs, outputs := trainDataMain("en")
+
+	for _, test := range []struct{ activation, loss string }{
+		{SigmoidActivation, MeanSquaredLoss},
+		{SigmoidActivation, BinaryCrossEntropyLoss},
+		{SoftmaxActivation, CrossEntropyLoss},
+	} {
+		t.Run(test.loss, func(t *testing.T) {
+			network := TrainNetwork("en", TrainingConfig{
+				HiddenLayers:     []HiddenLayer{{Nodes: 50, Activation: SigmoidActivation}},
+				OutputActivation: test.activation,
+				Loss:             test.loss,
+				Rate:             0.01,
+				Optimizer:        AdamOptimizer,
+				Epochs:           200,
+				Seed:             1,
+			}, inputs, outputs)
+			network.Words, network.Classes = words, classes
+			network.SetContext(TrainedIntents("en"))
+
+			for _, test := range []struct{ content, want string }{
+				{"Who are you", "identity"},
+				{"How old are you", "age"},
+				{"Goodbye", "goodbye"},
+				{"zqxjv wkpfh", DontUnderstand},
+			} {
+				tag, err := NewSentence("en", test.content).PredictTag(network)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tag != test.want {
+					results, _ := NewSentence("en", test.content).Predict(network)
+					t.Errorf("got the tag %q for %q, want %q, threshold %g, results %v", tag, test.content, test.want, network.ConfidenceThreshold(), results[:2])
+				}
+			}
+		})
+	}
+}
+
+// countingStemmer counts the words it stems
+type countingStemmer struct {
+	Stemmer
+	calls *int
+}
+
+func (stemmer countingStemmer) Stem(word string) string {
+	*stemmer.calls++
+	return stemmer.Stemmer.Stem(word)
+}
+
+func TestPredictTagUsesCompiledContext(t *testing.T) {
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_intents, err := ReadIntents("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The locale has no resources, reading them again would fail
+	var pipelines, stems int
+	RegisterPipeline("xx", func(locale string) Pipeline {
+		pipelines++
+		pipeline := DefaultPipeline("en")
+		pipeline.Stemmer = countingStemmer{pipeline.Stemmer, &stems}
+		return pipeline
+	})
+	defer delete(pipelineFactories, "xx")
+
+	network.Locale = "xx"
+	context := network.SetContext(_intents)
+
+	for i := 0; i < 3; i++ {
+		stems = 0
+		if _, err := NewSentence("xx", "hello there").PredictTag(*network); err != nil {
+			t.Fatal(err)
+		}
+
+		if stems == 0 || stems > 2 {
+			t.Errorf("%d words were stemmed for a sentence of 2 words", stems)
+		}
+	}
+
+	if compiled, _ := network.Context(); pipelines != 1 || compiled != context {
+		t.Errorf("the context was compiled again, %d pipelines were built", pipelines)
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	slot := Slot{Keyword: "remind me to"}
+	for _, test := range []struct {
+		sentence, want string
+	}{
+		{"Remind me to call Paul", "call Paul"},
+		{"please REMIND ME TO buy bread", "buy bread"},
+		// The lower case of Ⱥ is longer than it
+		{"ȺȺȺȺ remind me to water the plants", "water the plants"},
+		{"ȺȺȺȺ remind", ""},
+		{"remind me to", ""},
+		{"call Paul", ""},
+	} {
+		if text, found := ExtractText("en", test.sentence, slot); text != test.want || found != (test.want != "") {
+			t.Errorf("ExtractText(%q) = %q, %v, want %q", test.sentence, text, found, test.want)
+		}
+	}
+
+	if name, _ := ExtractName("en", "my name is john", Slot{}); name != "John" {
+		t.Errorf("got the name %q, want %q", name, "John")
+	}
+}
+
+func TestExtractGenres(t *testing.T) {
+	for _, test := range []struct {
+		sentence, want string
+	}{
+		{"I like comdy and thrillers", "Comedy, Thriller"},
+		{"a war movie", "War"},
+		// The short genres must be spelled exactly
+		{"I was in the car", ""},
+		{"hello", ""},
+	} {
+		if genres, found := ExtractGenres("en", test.sentence, Slot{}); genres != test.want || found != (test.want != "") {
+			t.Errorf("ExtractGenres(%q) = %q, %v, want %q", test.sentence, genres, found, test.want)
+		}
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	defer func(locales []Locale, fallbacks map[string][]string) {
+		Locales, LocaleFallbacks = locales, fallbacks
+	}(Locales, LocaleFallbacks)
+
+	Locales = []Locale{{Tag: "en"}, {Tag: "fr"}, {Tag: "pt"}, {Tag: "de"}, {Tag: "es"}, {Tag: "ca"}}
+	LocaleFallbacks = map[string][]string{"ca": {"es"}, "fr-CA": {"fr"}}
+
+	for _, test := range []struct {
+		accepted, want string
+	}{
+		{"fr-CA", "fr"},
+		{"pt_BR", "pt"},
+		{"en-GB;q=0.8,de;q=0.9", "de"},
+		{"ja,en-GB;q=0.5", "en"},
+		{"!!!,fr;q=0.5", "fr"},
+		{"ja", ""},
+		{"", ""},
+		{"  ", ""},
+		{"not a language", ""},
+	} {
+		if locale := NegotiateLocale(test.accepted); locale != test.want {
+			t.Errorf("NegotiateLocale(%q) = %q, want %q", test.accepted, locale, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		locale string
+		want   []string
+	}{
+		{"fr-CA", []string{"fr", "en"}},
+		{"pt_BR", []string{"pt", "en"}},
+		{"ca", []string{"ca", "es", "en"}},
+		{"en-GB", []string{"en"}},
+		{"ja", []string{"en"}},
+		{"", []string{"en"}},
+		{"not a language", []string{"en"}},
+	} {
+		if chain := LocaleChain(test.locale); !reflect.DeepEqual(chain, test.want) {
+			t.Errorf("LocaleChain(%q) = %v, want %v", test.locale, chain, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		tag  string
+		want []string
+	}{
+		// The fallback is already a parent, it isn't repeated
+		{"fr-CA", []string{"fr-CA", "fr"}},
+		{"pt_BR", []string{"pt-BR", "pt"}},
+		{"ca", []string{"ca", "es"}},
+		{"garbage!", []string{"garbage!"}},
+	} {
+		if candidates := appendLocaleCandidates(nil, test.tag); !reflect.DeepEqual(candidates, test.want) {
+			t.Errorf("appendLocaleCandidates(%q) = %v, want %v", test.tag, candidates, test.want)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	defer CacheIntents("xx", nil)
+	defer delete(modulesf, "xx")
+
+	// English has more intents than modules
+	defaultIntents := []Intent{{Tag: "a"}, {Tag: "b"}, {Tag: "c"}, {Tag: "d"}}
+	defaultModules := []Modulef{{Tag: "m"}, {Tag: "n"}}
+	CacheIntents("xx", []Intent{{Tag: "a"}, {Tag: "b"}, {Tag: "c"}})
+	RegisterModulef("xx", Modulef{Tag: "m"})
+
+	coverage := func(details CoverageDetails) string {
+		if details.Coverage == nil {
+			return "N/A"
+		}
+		return fmt.Sprint(*details.Coverage)
+	}
+
+	for _, test := range []struct {
+		name          string
+		details       CoverageDetails
+		want          string
+		notCoveredLen int
+	}{
+		{"intents", getIntentCoverage("xx", defaultIntents), "75", 1},
+		{"modules", getModuleCoverage("xx", defaultModules), "50", 1},
+		{"no English intents", getIntentCoverage("xx", nil), "N/A", 0},
+		{"no English messages", getMessageCoverage("xx", nil), "N/A", 0},
+	} {
+		if got := coverage(test.details); got != test.want || len(test.details.NotCovered) != test.notCoveredLen {
+			t.Errorf("%s: got the coverage %s with %v not covered, want %s", test.name, got, test.details.NotCovered, test.want)
+		}
+	}
+
+	var output strings.Builder
+	LocaleCoverage{Tag: "xx", Coverage: Coverage{Intents: getIntentCoverage("xx", defaultIntents)}}.Print(&output)
+	if !strings.Contains(output.String(), "75%") || !strings.Contains(output.String(), "N/A") {
+		t.Errorf("the report doesn't show the coverages:\n%s", output.String())
+	}
+}
+
+func TestEarlyStoppingRestoresBestWeights(t *testing.T) {
+	_, _, inputs, outputs := trainDataMain("en")
+	config := TrainingConfig{
+		HiddenLayers:     []HiddenLayer{{Nodes: 50, Activation: SigmoidActivation}},
+		OutputActivation: SoftmaxActivation,
+		Rate:             0.05,
+		Optimizer:        AdamOptimizer,
+		Epochs:           300,
+		Patience:         5,
+		ValidationSplit:  0.3,
+		Seed:             1,
+	}
+	network := TrainNetwork("en", config, inputs, outputs)
+
+	if network.BestEpoch == 0 || network.BestEpoch >= network.Epochs || network.Epochs >= config.Epochs {
+		t.Fatalf("the training didn't stop early: best epoch %d of %d", network.BestEpoch, network.Epochs)
+	}
+
+	// The validation set is the first draw of the seeded source
+	_, _, validationInputs, validationOutputs := SplitTrainingData(inputs, outputs, config.ValidationSplit, rand.New(rand.NewSource(config.Seed)))
+	loss := network.ComputeLoss(network.Forward(validationInputs), validationOutputs)
+	if want := network.ValidationLoss[network.BestEpoch-1]; loss != want {
+		t.Errorf("the validation loss of the weights is %v, the one of the best epoch is %v", loss, want)
+	}
+	if best := slices.Min(network.ValidationLoss); network.ValidationLoss[network.BestEpoch-1] != best {
+		t.Errorf("the best epoch has the loss %v, the lowest is %v", network.ValidationLoss[network.BestEpoch-1], best)
+	}
+}
+
+func TestSplitFolds(t *testing.T) {
+	_, _, _, outputs := trainDataMain("en")
+
+	for _, folds := range []int{2, 5, 10} {
+		seen := make([]int, Rows(outputs))
+		for fold, indexes := range SplitFolds(outputs, folds) {
+			if len(indexes) == 0 {
+				t.Errorf("the fold %d of %d is empty", fold, folds)
+			}
+
+			for _, i := range indexes {
+				seen[i]++
+			}
+		}
+
+		for i, count := range seen {
+			if count != 1 {
+				t.Errorf("the sample %d is in %d of the %d folds", i, count, folds)
+			}
+		}
+	}
+}
+
+func TestPredictWithoutDropout(t *testing.T) {
+	network := gradientNetwork(t, SigmoidActivation, "")
+	network.Dropout = 0.5
+	input := network.Layers[0].Row(0)
+
+	// The training steps drop units
+	network.feedForward(network.Dropout)
+	dropped := slices.Clone(network.Layers[len(network.Layers)-1].Row(0))
+
+	network.FeedForward()
+	want := network.Layers[len(network.Layers)-1].Row(0)
+	if reflect.DeepEqual(dropped, want) {
+		t.Fatal("the dropout didn't change the output")
+	}
+
+	for i := 0; i < 3; i++ {
+		if prediction := network.Predict(input); !reflect.DeepEqual(prediction, want) {
+			t.Fatalf("got the prediction %v, want %v without dropout", prediction, want)
+		}
+	}
+}
+
+func TestSpellingCorrection(t *testing.T) {
+	defer func(enabled bool) { SpellingCorrection = enabled }(SpellingCorrection)
+	SpellingCorrection = true
+
+	network, err := LoadNetwork("testdata/training.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	context := network.SetContext(TrainedIntents("en"))
+
+	bag, corrections := context.CorrectedWordsBag(NewSentence("en", "helo"))
+	if !reflect.DeepEqual(corrections, []Correction{{From: "helo", To: "hello"}}) {
+		t.Errorf("got the corrections %v", corrections)
+	}
+	if want := context.WordsBag(NewSentence("en", "hello")); !reflect.DeepEqual(bag, want) {
+		t.Error("the corrected sentence doesn't have the words of hello")
+	}
+
+	if tag, err := NewSentence("en", "helo").PredictTag(*network); err != nil || tag != "hello" {
+		t.Errorf("got the tag %q, %v for helo", tag, err)
+	}
+}
+
+func TestAugmentPatternIsDeterministic(t *testing.T) {
+	augmentation, err := ReadAugmentation("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{"What is your name", "Tell me a joke", "How are you"} {
+		variants := augmentation.AugmentPattern(pattern, 5)
+		if len(variants) == 0 {
+			t.Errorf("no variants were generated for %q", pattern)
+		}
+
+		for i := 0; i < 3; i++ {
+			if again := augmentation.AugmentPattern(pattern, 5); !reflect.DeepEqual(again, variants) {
+				t.Errorf("the variants of %q changed: %v and %v", pattern, variants, again)
+			}
+		}
+
+		if slices.Contains(variants, pattern) {
+			t.Errorf("the pattern %q is one of its variants", pattern)
+		}
+	}
+}
@@ -15,6 +15,17 @@ func init() {
 	RegisterRule(RuleDate)
 }
 
+func init() {
+	// Register the entities of the slots
+	RegisterEntity("date", ExtractDate)
+	RegisterEntity("time", ExtractTime)
+	RegisterEntity("number", ExtractNumber)
+	RegisterEntity("country", ExtractCountry)
+	RegisterEntity("name", ExtractName)
+	RegisterEntity("genre", ExtractGenres)
+	RegisterEntity("text", ExtractText)
+}
+
 func init() {
 	// Set default value of the callback url
 	if callbackURL == "" {
@@ -42,256 +53,25 @@ func init() {
 }
 
 func init() {
-	RegisterModulesf("en", []Modulef{
-		// AREA
-		// For modules related to countries, please add the translations of the countries' names
-		// or open an issue to ask for translations.
-
-		{
-			Tag: AreaTag,
-			Patterns: []string{
-				"What is the area of ",
-				"Give me the area of ",
-			},
-			Responses: []string{
-				"The area of %s is %gkm²",
-			},
-			Replacer: AreaReplacer,
-		},
-
-		// CAPITAL
-		{
-			Tag: CapitalTag,
-			Patterns: []string{
-				"What is the capital of ",
-				"What's the capital of ",
-				"Give me the capital of ",
-			},
-			Responses: []string{
-				"The capital of %s is %s",
-			},
-			Replacer: CapitalReplacer,
-		},
-
-		// CURRENCY
-		{
-			Tag: CurrencyTag,
-			Patterns: []string{
-				"Which currency is used in ",
-				"Give me the used currency of ",
-				"Give me the currency of ",
-				"What is the currency of ",
-			},
-			Responses: []string{
-				"The currency of %s is %s",
-			},
-			Replacer: CurrencyReplacer,
-		},
-
-		// MATH
-		// A regex translation is also required in `language/math.go`, please don't forget to translate it.
-		// Otherwise, remove the registration of the Math module in this file.
-
-		{
-			Tag: MathTag,
-			Patterns: []string{
-				"Give me the result of ",
-				"Calculate ",
-			},
-			Responses: []string{
-				"The result is %s",
-				"That makes %s",
-			},
-			Replacer: MathReplacer,
-		},
-
-		// MOVIES
-		// A translation of movies genres is also required in `language/movies.go`, please don't forget
-		// to translate it.
-		// Otherwise, remove the registration of the Movies modules in this file.
-
-		{
-			Tag: GenresTag,
-			Patterns: []string{
-				"My favorite movie genres are Comedy, Horror",
-				"I like the Comedy, Horror genres",
-				"I like movies about War",
-				"I like Action movies",
-			},
-			Responses: []string{
-				"Great choices! I saved this movie genre information to your client.",
-				"Understood, I saved this movie genre information to your client.",
-			},
-			Replacer: GenresReplacer,
-		},
-
-		{
-			Tag: MoviesTag,
-			Patterns: []string{
-				"Find me a movie about",
-				"Give me a movie about",
-				"Find me a film about",
-			},
-			Responses: []string{
-				"I found the movie “%s” for you, which is rated %.02f/5",
-				"Sure, I found this movie “%s”, which is rated %.02f/5",
-			},
-			Replacer: MovieSearchReplacer,
-		},
-
-		{
-			Tag: MoviesAlreadyTag,
-			Patterns: []string{
-				"I already saw this movie",
-				"I have already watched this film",
-				"Oh I have already watched this movie",
-				"I have already seen this movie",
-			},
-			Responses: []string{
-				"Oh I see, here's another one “%s” which is rated %.02f/5",
-			},
-			Replacer: MovieSearchReplacer,
-		},
-
-		{
-			Tag: MoviesDataTag,
-			Patterns: []string{
-				"I'm bored",
-				"I don't know what to do",
-			},
-			Responses: []string{
-				"I propose you watch the %s movie “%s”, which is rated %.02f/5",
-			},
-			Replacer: MovieSearchFromInformationReplacer,
-		},
-
-		// NAME
-		{
-			Tag: NameGetterTag,
-			Patterns: []string{
-				"Do you know my name?",
-			},
-			Responses: []string{
-				"Your name is %s!",
-			},
-			Replacer: NameGetterReplacer,
-		},
-
-		{
-			Tag: NameSetterTag,
-			Patterns: []string{
-				"My name is ",
-				"You can call me ",
-			},
-			Responses: []string{
-				"Great! Hi %s",
-			},
-			Replacer: NameSetterReplacer,
-		},
-
-		// RANDOM
-		{
-			Tag: RandomTag,
-			Patterns: []string{
-				"Give me a random number",
-				"Generate a random number",
-			},
-			Responses: []string{
-				"The number is %s",
-			},
-			Replacer: RandomNumberReplacer,
-		},
-
-		// REMINDERS
-		// Translations are required in `language/date/date`, `language/date/rules` and in `language/reason`,
-		// please don't forget to translate it.
-		// Otherwise, remove the registration of the Reminders modules in this file.
-
-		{
-			Tag: ReminderSetterTag,
-			Patterns: []string{
-				"Remind me to cook a breakfast at 8pm",
-				"Remind me to call mom tuesday",
-				"Note that I have an exam",
-				"Remind me that I have a conference call tomorrow at 9pm",
-			},
-			Responses: []string{
-				"Noted! I will remind you: “%s” for the %s",
-			},
-			Replacer: ReminderSetterReplacer,
-		},
-
-		{
-			Tag: ReminderGetterTag,
-			Patterns: []string{
-				"What did I ask for you to remember",
-				"Give me my reminders",
-			},
-			Responses: []string{
-				"You asked me to remember those things:\n%s",
-			},
-			Replacer: ReminderGetterReplacer,
-		},
-
-		// SPOTIFY
-		// A translation is needed in `language/music`, please don't forget to translate it.
-		// Otherwise, remove the registration of the Spotify modules in this file.
-
-		{
-			Tag: SpotifySetterTag,
-			Patterns: []string{
-				"Here are my spotify tokens",
-				"My spotify secrets",
-			},
-			Responses: []string{
-				"Login in progress",
-			},
-			Replacer: SpotifySetterReplacer,
-		},
-
-		{
-			Tag: SpotifyPlayerTag,
-			Patterns: []string{
-				"Play from on Spotify",
-			},
-			Responses: []string{
-				"Playing %s from %s on Spotify.",
-			},
-			Replacer: SpotifyPlayerReplacer,
-		},
-
-		{
-			Tag: JokesTag,
-			Patterns: []string{
-				"Tell me a joke",
-				"Make me laugh",
-			},
-			Responses: []string{
-				"Here you go, %s",
-				"Here's one, %s",
-			},
-			Replacer: JokesReplacer,
-		},
-		{
-			Tag: AdvicesTag,
-			Patterns: []string{
-				"Give me an advice",
-				"Advise me",
-			},
-			Responses: []string{
-				"Here you go, %s",
-				"Here's one, %s",
-				"Listen closely, %s",
-			},
-			Replacer: AdvicesReplacer,
-		},
-	})
-
-	// COUNTRIES
-	// Please translate this method for adding the correct article in front of countries names.
-	// Otherwise, remove the countries modules from this file.
-
-	ArticleCountries["en"] = ArticleCountriesOut
+	// Register the replacers of the modules, their patterns and responses are in the modules.json
+	// of the locale packs
+	RegisterModuleReplacer(AreaTag, AreaReplacer)
+	RegisterModuleReplacer(CapitalTag, CapitalReplacer)
+	RegisterModuleReplacer(CurrencyTag, CurrencyReplacer)
+	RegisterModuleReplacer(MathTag, MathReplacer)
+	RegisterModuleReplacer(GenresTag, GenresReplacer)
+	RegisterModuleReplacer(MoviesTag, MovieSearchReplacer)
+	RegisterModuleReplacer(MoviesAlreadyTag, MovieSearchReplacer)
+	RegisterModuleReplacer(MoviesDataTag, MovieSearchFromInformationReplacer)
+	RegisterModuleReplacer(NameGetterTag, NameGetterReplacer)
+	RegisterModuleReplacer(NameSetterTag, NameSetterReplacer)
+	RegisterModuleReplacer(RandomTag, RandomNumberReplacer)
+	RegisterModuleReplacer(ReminderSetterTag, ReminderSetterReplacer)
+	RegisterModuleReplacer(ReminderGetterTag, ReminderGetterReplacer)
+	RegisterModuleReplacer(SpotifySetterTag, SpotifySetterReplacer)
+	RegisterModuleReplacer(SpotifyPlayerTag, SpotifyPlayerReplacer)
+	RegisterModuleReplacer(JokesTag, JokesReplacer)
+	RegisterModuleReplacer(AdvicesTag, AdvicesReplacer)
 }
 
 // =================================================================
@@ -1,7 +1,12 @@
 package olivia
 
 import (
+	"encoding/xml"
+	"github.com/tebeka/snowball"
 	"golang.org/x/oauth2"
+	"golang.org/x/text/language"
+	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -39,9 +44,23 @@ type NetworkLayersData struct {
 }
 
 type TrainingInfoData struct {
-	LearningRate float64   `json:"rate"`
-	ErrorMetrics []float64 `json:"errors"`
-	TrainingTime float64   `json:"time"`
+	LearningRate       float64   `json:"rate"`
+	ErrorMetrics       []float64 `json:"errors"`
+	TrainingTime       float64   `json:"time"`
+	Optimizer          string    `json:"optimizer"`
+	BatchSize          int       `json:"batch_size"`
+	ValidationSplit    float64   `json:"validation_split"`
+	Epochs             int       `json:"epochs"`
+	BestEpoch          int       `json:"best_epoch"`
+	TrainingLoss       []float64 `json:"training_loss"`
+	TrainingAccuracy   []float64 `json:"training_accuracy"`
+	ValidationLoss     []float64 `json:"validation_loss"`
+	ValidationAccuracy []float64 `json:"validation_accuracy"`
+	Dropout            float64   `json:"dropout"`
+	WeightDecay        float64   `json:"weight_decay"`
+	GradientClipping   float64   `json:"gradient_clipping"`
+	Seed               int64     `json:"seed"`
+	Augmentation       int       `json:"augmentation"`
 }
 
 type clientRequestMessage struct {
@@ -50,30 +69,199 @@ type clientRequestMessage struct {
 	Token       string      `json:"user_token"`
 	Locale      string      `json:"locale"`
 	Information UserProfile `json:"information"`
+	// Alternatives is the number of ranked intents sent back with the confidence, 0 for none
+	Alternatives int `json:"alternatives"`
 }
 
 type serverResponseMessage struct {
 	Content     string      `json:"content"`
 	Tag         string      `json:"tag"`
 	Information UserProfile `json:"information"`
+	// Confidence and Alternatives are only sent when the client asks for alternatives
+	Confidence   *float64 `json:"confidence,omitempty"`
+	Alternatives []Result `json:"alternatives,omitempty"`
+	// Slots are the values found for the slots of the intent
+	Slots map[string]string `json:"slots,omitempty"`
+	// Locale is the locale resolved for the reply and Detected the language detected in the message
+	Locale   string  `json:"locale,omitempty"`
+	Detected *Result `json:"detected,omitempty"`
 }
 
 type LayerDerivative struct {
-	Delta      Matrix
-	Adjustment Matrix
+	Delta          Matrix
+	Adjustment     Matrix
+	BiasAdjustment Matrix
+	// activation keeps the derivative of the activation of the layer between the steps
+	activation Matrix
 }
 
-type Matrix [][]float64
+// Matrix stores its rows one after the other in a single slice, it is saved as a slice of rows
+type Matrix struct {
+	rows    int
+	columns int
+	data    []float64
+}
 
+// Network is saved without its training matrices, Layers and Output are only filled while
+// the network is trained.
 type Network struct {
-	Layers  []Matrix
-	Weights []Matrix
-	Biases  []Matrix
-	Output  Matrix
+	Layers           []Matrix `json:"-"`
+	Weights          []Matrix
+	Biases           []Matrix
+	Output           Matrix `json:"-"`
+	ValidationInput  Matrix `json:"-"`
+	ValidationOutput Matrix `json:"-"`
+	Words            []string
+	Classes          []string
+	Version          int
+	CreatedAt        time.Time
+	DataHash         string
+	Rate             float64
+	Errors           []float64
+	Time             float64
+	Locale           string
+	Activations      []string
+	OutputActivation string
+	Loss             string
+	Optimizer        string
+	BatchSize        int
+	Shuffle          bool
+	ValidationSplit  float64
+	Patience         int
+	Dropout          float64
+	WeightDecay      float64
+	GradientClipping float64
+	// Seed is the seed of the random source of the training, the same seed and data give the same
+	// weights
+	Seed int64
+	// Augmentation is the maximum number of variants of each pattern the network was trained with
+	Augmentation int
+	// The metrics of each epoch, the validation ones are empty without a validation split
+	Epochs             int
+	BestEpoch          int
+	TrainingLoss       []float64
+	TrainingAccuracy   []float64
+	ValidationLoss     []float64
+	ValidationAccuracy []float64
+	// samples is the number of training samples, the adjustments of a batch are scaled to it
+	samples int
+	// derivatives are reused by each step of the training
+	derivatives []LayerDerivative
+	// dropoutMasks are the scales of the units of the hidden layers at the current step, 0 for the
+	// dropped units
+	dropoutMasks []Matrix
+	random       *rand.Rand
+	// context is compiled for Words when the network is loaded or trained
+	context *NLPContext
+}
+
+type CrossValidationReport struct {
+	Locale   string          `json:"locale"`
+	Folds    int             `json:"folds"`
+	Accuracy float64         `json:"accuracy"`
+	Intents  []IntentMetrics `json:"intents"`
+	Classes  []string        `json:"classes"`
+	// ConfusionMatrix counts the predictions, its rows are the expected classes and its columns
+	// the predicted ones.
+	ConfusionMatrix [][]int `json:"confusion_matrix"`
+}
+
+type IntentMetrics struct {
+	Tag       string  `json:"tag"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"`
+}
+
+// ModelError is returned when a model can't be saved or loaded
+type ModelError struct {
+	FileName string
+	Err      error
+}
+
+type HiddenLayer struct {
+	Nodes      int
+	Activation string
+}
+
+// Activation is an activation function with its derivative, the derivative is expressed with the
+// activated value since it is the one kept in the layers.
+type Activation struct {
+	Function   func(x float64) float64
+	Derivative func(y float64) float64
+	// He is true for the rectifiers which are initialized with the He method instead of Xavier
+	He bool
+}
+
+type TrainingConfig struct {
+	HiddenLayers     []HiddenLayer
+	OutputActivation string
+	// Loss is the loss of the output layer, empty for the one of OutputActivation: the mean squared
+	// error for a sigmoid and the cross-entropy for a softmax
+	Loss string
+	// Rate multiplies the adjustments summed over the training set, a batch is scaled to stand for
+	// the whole set
+	Rate      float64
+	Optimizer string
+	// BatchSize is the number of samples for each adjustment, 0 trains on the full batch
+	BatchSize int
+	Shuffle   bool
+	// Epochs is the maximum number of epochs, the training stops before when the validation loss
+	// hasn't improved during Patience epochs.
+	Epochs   int
+	Patience int
+	// ValidationSplit is the part of the patterns of each intent kept aside to validate the model
+	ValidationSplit float64
+	// Dropout is the probability of dropping each unit of the hidden layers during the training
+	Dropout float64
+	// WeightDecay is the L2 penalty, the weights lose this part of their value at each step
+	WeightDecay float64
+	// GradientClipping is the maximum norm of the adjustments of a step, 0 disables it
+	GradientClipping float64
+	// Seed initializes the random source of the training, 0 picks a new one which is saved with
+	// the model
+	Seed int64
+	// Augmentation is the maximum number of variants generated for each pattern, 0 disables it
+	Augmentation int
+}
+
+// Augmentation holds the tables of ../res/locales/<locale>/augmentation.json used to generate
+// variants of the patterns
+type Augmentation struct {
+	// Synonyms are groups of words which can replace each other
+	Synonyms [][]string `json:"synonyms"`
+	// Keyboard are the rows of the locale's keyboard, the typos replace a letter by its neighbor
+	Keyboard []string `json:"keyboard"`
+
+	synonyms  map[string][]string
+	neighbors map[rune][]rune
+}
+
+// Optimizer applies the adjustments computed by the backpropagation to the parameters of a network,
+// its state is kept apart from the weights so that it isn't saved with the model.
+type Optimizer interface {
+	Update(parameters, adjustments []Matrix)
+}
+
+type SGD struct {
+	Rate float64
+}
+
+type Momentum struct {
+	Rate       float64
+	Momentum   float64
+	velocities []Matrix
+}
+
+type Adam struct {
 	Rate    float64
-	Errors  []float64
-	Time    float64
-	Locale  string
+	Beta1   float64
+	Beta2   float64
+	Epsilon float64
+	step    int
+	moments []Matrix
+	squares []Matrix
 }
 
 type LocaleCoverage struct {
@@ -86,11 +274,84 @@ type Coverage struct {
 	Modules  CoverageDetails `json:"modules"`
 	Intents  CoverageDetails `json:"intents"`
 	Messages CoverageDetails `json:"messages"`
+	// Tables are the entries of the locale pack like the date rules, the keywords and the genres
+	Tables    CoverageDetails `json:"tables"`
+	Countries CoverageDetails `json:"countries"`
+	// Placeholders are the translated texts whose verbs and slots differ from the English ones
+	Placeholders []PlaceholderMismatch `json:"placeholders"`
+	// Patterns compares the number of patterns of the translated intents and modules
+	Patterns []PatternParity `json:"patterns"`
+}
+
+// PlaceholderMismatch is a translated response or message which doesn't have the %s, %g... verbs
+// or the {slots} of the English one
+type PlaceholderMismatch struct {
+	Resource string   `json:"resource"`
+	Tag      string   `json:"tag"`
+	Text     string   `json:"text"`
+	Expected []string `json:"expected"`
+	Found    []string `json:"found"`
+}
+
+// TranslationUnit is a message, a pattern or a response in English and in the translated locale,
+// its key is made of the file, the tag and the index like messages/<tag>/<index>
+type TranslationUnit struct {
+	Key    string
+	Source string
+	Target string
+}
+
+// TranslationImport reports the entries merged into a locale by ImportTranslations
+type TranslationImport struct {
+	Locale  string   `json:"locale"`
+	Updated []string `json:"updated"`
+	// Missing are the English entries which still aren't translated
+	Missing []string `json:"missing"`
+	// Stale are the entries whose English text changed since they were exported, or which don't
+	// exist anymore, they aren't imported
+	Stale []string `json:"stale"`
+}
+
+// xliffDocument is a XLIFF 2.0 document with a file for the messages and one for the intents
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version string      `xml:"version,attr"`
+	Source  string      `xml:"srcLang,attr"`
+	Target  string      `xml:"trgLang,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	ID    string      `xml:"id,attr"`
+	Units []xliffUnit `xml:"unit"`
+}
+
+// xliffUnit holds the key of the translation in its name since the keys aren't valid ids
+type xliffUnit struct {
+	ID      string       `xml:"id,attr"`
+	Name    string       `xml:"name,attr"`
+	Segment xliffSegment `xml:"segment"`
+}
+
+type xliffSegment struct {
+	State  string  `xml:"state,attr,omitempty"`
+	Source string  `xml:"source"`
+	Target *string `xml:"target"`
+}
+
+// PatternParity is the number of patterns of an intent or a module in a locale and in English
+type PatternParity struct {
+	Resource        string `json:"resource"`
+	Tag             string `json:"tag"`
+	Patterns        int    `json:"patterns"`
+	DefaultPatterns int    `json:"default_patterns"`
 }
 
 type CoverageDetails struct {
 	NotCovered []string `json:"not_covered"`
-	Coverage   int      `json:"coverage"`
+	// Coverage is the percentage of the English resources which are translated, null when English
+	// has none
+	Coverage *int `json:"coverage"`
 }
 
 type Intent struct {
@@ -98,6 +359,19 @@ type Intent struct {
 	Patterns  []string `json:"patterns"`
 	Responses []string `json:"responses"`
 	Context   string   `json:"context"`
+	// Slots are the values taken from the sentence which the responses use as {name}
+	Slots []Slot `json:"slots,omitempty"`
+}
+
+// Slot is a value of a sentence found by the extractor of an entity like "date" or "country"
+type Slot struct {
+	Name   string `json:"name"`
+	Entity string `json:"entity"`
+	// Keyword precedes the value of the "text" entity
+	Keyword  string `json:"keyword,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// Prompt is the response sent when a required slot isn't in the sentence
+	Prompt string `json:"prompt,omitempty"`
 }
 
 type Document struct {
@@ -110,9 +384,104 @@ type Sentence struct {
 	Content string
 }
 
+// NLPContext is compiled once from the resources and the model of a locale. It isn't modified so
+// the requests can share it, it is replaced as a whole when the locale is re-trained.
+type NLPContext struct {
+	Locale      string
+	Words       []string
+	WordIndexes map[string]int
+	Classes     []string
+	Pipeline    Pipeline
+	// Intents are the intents of intents.json followed by the ones of the modules
+	Intents []Intent
+}
+
+// Pipeline turns the content of a sentence into the words given to the model, each stage can be
+// replaced by the locales which need it
+type Pipeline struct {
+	Normalizer Normalizer
+	Tokenizer  Tokenizer
+	Corrector  Corrector
+	StopWords  StopWordFilter
+	Stemmer    Stemmer
+}
+
+type Normalizer interface {
+	Normalize(content string) string
+}
+
+type Tokenizer interface {
+	Tokenize(content string) []string
+}
+
+// Corrector replaces the misspelled tokens and returns the corrections it made
+type Corrector interface {
+	Correct(tokens []string) ([]string, []Correction)
+}
+
+type StopWordFilter interface {
+	Filter(tokens []string) []string
+}
+
+type Stemmer interface {
+	Stem(word string) string
+}
+
+// UnicodeNormalizer composes the characters and folds their case with the rules of Language
+type UnicodeNormalizer struct {
+	Language language.Tag
+	// FoldAccents removes the accents, "café" becomes "cafe"
+	FoldAccents bool
+}
+
+// UnicodeTokenizer splits the words on the characters which aren't letters or digits, the scripts
+// which don't separate their words are split by Segmenter
+type UnicodeTokenizer struct {
+	Segmenter Segmenter
+}
+
+// Segmenter splits the text of a script which doesn't separate its words
+type Segmenter interface {
+	Segment(text string) []string
+}
+
+// DictionarySegmenter splits the text in the words of its dictionary
+type DictionarySegmenter struct {
+	words     map[string]bool
+	maxLength int
+}
+
+// SpellingCorrector corrects the tokens it doesn't know with the words of the intents and of the
+// gazetteers
+type SpellingCorrector struct {
+	MaxDistance int
+	known       map[string]bool
+	words       *BKTree
+	names       *BKTree
+}
+
+// Correction is a misspelled word and the word which replaced it
+type Correction struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StopWordSet removes the tokens which are in the set
+type StopWordSet map[string]bool
+
+// legacyNormalizer and legacyStopWords are the stages of LegacyPipeline
+type legacyNormalizer struct{}
+
+type legacyStopWords []string
+
+type SnowballStemmer struct {
+	mutex   sync.Mutex
+	stemmer *snowball.Stemmer
+}
+
 type Result struct {
-	Tag   string
-	Value float64
+	Tag   string  `json:"tag"`
+	Value float64 `json:"value"`
 }
 
 type Error struct {
@@ -128,12 +497,54 @@ type Locale struct {
 	Name string
 }
 
+// LocalePack is the manifest of a locale, res/locales/<tag>/locale.json, it holds the tables of
+// the locale which aren't in its intents, messages and modules files
+type LocalePack struct {
+	Tag  string `json:"tag"`
+	Name string `json:"name"`
+	// Disabled packs are skipped at startup, their translations are kept until they are complete
+	Disabled bool `json:"disabled,omitempty"`
+	// Fallbacks are the locales whose resources are used before the parent language's ones
+	Fallbacks       []string            `json:"fallbacks,omitempty"`
+	Rules           RuleTranslation     `json:"rules"`
+	Patterns        PatternTranslations `json:"patterns"`
+	ReasonKeywords  ReasonKeyword       `json:"reason_keywords"`
+	SpotifyKeywords SpotifyKeywords     `json:"spotify_keywords"`
+	MoviesGenres    []string            `json:"movies_genres"`
+	MathDecimals    string              `json:"math_decimals"`
+	// CountriesArticles are the names of the countries written with their article
+	CountriesArticles map[string]string `json:"countries_articles,omitempty"`
+	// Modules are read from res/locales/<tag>/modules.json
+	Modules []Modulef `json:"-"`
+}
+
 type Modulef struct {
-	Tag       string
-	Patterns  []string
-	Responses []string
-	Replacer  func(string, string, string, string) (string, string)
-	Context   string
+	Tag       string                                                `json:"tag"`
+	Patterns  []string                                              `json:"patterns"`
+	Responses []string                                              `json:"responses"`
+	Replacer  func(string, string, string, string) (string, string) `json:"-"`
+	Context   string                                                `json:"context,omitempty"`
+}
+
+// ModuleReplacer fills the response of a module with the sentence, it returns the tag and the
+// response
+type ModuleReplacer func(locale, entry, response, token string) (string, string)
+
+// ReloadEvent is a reload of the resources of a locale, or a retrain of its network
+type ReloadEvent struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Locale string    `json:"locale"`
+	// Files are the files whose change started the reload
+	Files           []string `json:"files,omitempty"`
+	PatternsChanged bool     `json:"patterns_changed,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// fileState is used to find the files of the locale packs which changed
+type fileState struct {
+	ModTime time.Time
+	Size    int64
 }
 
 type Joke struct {
@@ -148,14 +559,14 @@ type Module struct {
 }
 
 type ReasonKeyword struct {
-	That string
-	To   string
+	That string `json:"that"`
+	To   string `json:"to"`
 }
 
 type SpotifyKeywords struct {
-	Play string
-	From string
-	On   string
+	Play string `json:"play"`
+	From string `json:"from"`
+	On   string `json:"on"`
 }
 
 type Movie struct {
@@ -164,6 +575,35 @@ type Movie struct {
 	Rating float64
 }
 
+// BKTree indexes words by their Levenshtein distance
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+type bkNode struct {
+	word     string
+	children map[int]*bkNode
+}
+
+// FuzzyMatch is a word found in a BKTree and its distance to the searched word
+type FuzzyMatch struct {
+	Word     string
+	Distance int
+}
+
+// KeywordIndex finds the keywords written with typos, each keyword has its maximum distance
+type KeywordIndex struct {
+	words        *BKTree
+	maxDistances map[string]int
+	maxDistance  int
+}
+
+// localeKeywords are the indexes of the keywords of a locale pack
+type localeKeywords struct {
+	genres, spotify, reason *KeywordIndex
+}
+
 type Country struct {
 	Name     map[string]string `json:"name"`
 	Capital  string            `json:"capital"`
@@ -174,20 +614,31 @@ type Country struct {
 
 type Rule func(string, string) time.Time
 
+// LanguageIdentifier guesses the locale of a sentence with the probabilities of its character
+// n-grams in each locale
+type LanguageIdentifier struct {
+	profiles map[string]map[string]float64
+	// unknown is the log-probability of the n-grams which aren't in the locale's profile
+	unknown map[string]float64
+}
+
+// EntityExtractor returns the value of the slot's entity in the sentence and whether it was found
+type EntityExtractor func(locale, sentence string, slot Slot) (string, bool)
+
 type RuleTranslation struct {
-	DaysOfWeek        []string
-	Months            []string
-	RuleToday         string
-	RuleTomorrow      string
-	RuleAfterTomorrow string
-	RuleDayOfWeek     string
-	RuleNextDayOfWeek string
-	RuleNaturalDate   string
+	DaysOfWeek        []string `json:"days_of_week"`
+	Months            []string `json:"months"`
+	RuleToday         string   `json:"today"`
+	RuleTomorrow      string   `json:"tomorrow"`
+	RuleAfterTomorrow string   `json:"after_tomorrow"`
+	RuleDayOfWeek     string   `json:"day_of_week"`
+	RuleNextDayOfWeek string   `json:"next_day_of_week"`
+	RuleNaturalDate   string   `json:"natural_date"`
 }
 
 type PatternTranslations struct {
-	DateRegex string
-	TimeRegex string
+	DateRegex string `json:"date"`
+	TimeRegex string `json:"time"`
 }
 
 // =================================================================
@@ -1,12 +1,17 @@
 package olivia
 
 import (
+	"errors"
 	"github.com/gorilla/websocket"
 	gocache "github.com/patrickmn/go-cache"
 	"github.com/zmb3/spotify"
 	"golang.org/x/oauth2"
+	"math"
+	"math/rand"
 	"net/http"
 	"os"
+	"regexp"
+	"sync"
 	"time"
 )
 
@@ -30,145 +35,77 @@ var websocketUpgrader = websocket.Upgrader{
 	},
 }
 
-var (
-	defaultModules  []Modulef
-	defaultIntents  []Intent
-	defaultMessages []DataPacket
-)
-
 var intents = map[string][]Intent{}
 
 var userCache = gocache.New(5*time.Minute, 5*time.Minute)
 
-var Locales = []Locale{
-	{
-		Tag:  "en",
-		Name: "english",
-	},
-	// {
-	// 	Tag:  "de",
-	// 	Name: "german",
-	// },
-	// {
-	// 	Tag:  "fr",
-	// 	Name: "french",
-	// },
-	// {
-	// 	Tag:  "es",
-	// 	Name: "spanish",
-	// },
-	// {
-	// 	Tag:  "ca",
-	// 	Name: "catalan",
-	// },
-	// {
-	// 	Tag:  "it",
-	// 	Name: "italian",
-	// },
-	// {
-	// 	Tag:  "tr",
-	// 	Name: "turkish",
-	// },
-	// {
-	// 	Tag:  "nl",
-	// 	Name: "dutch",
-	// },
-	// {
-	// 	Tag:  "el",
-	// 	Name: "greek",
-	// },
-}
+// Locales are the locales whose pack was found in res/locales
+var Locales []Locale
+
+// DefaultLocale ends the fallback chains of the locales
+var DefaultLocale = "en"
+
+// LocaleFallbacks are the locales tried after a locale and before its parent language, e.g.
+// "ca": {"es"}
+var LocaleFallbacks = map[string][]string{}
+
+// localePacks holds the installed pack of each locale, the coverage compares them
+var localePacks = map[string]LocalePack{}
+
+// localesDirectory holds a pack for each locale, a directory named after its tag
+var localesDirectory = "../res/locales"
+
+// localePackFiles are the files that every locale pack needs besides its locale.json
+var localePackFiles = []string{"intents.json", "messages.json", "stopwords.txt", "modules.json"}
+
+// localePackOptionalFiles are the files of a locale pack which are also watched for changes
+var localePackOptionalFiles = []string{"augmentation.json", "dictionary.txt"}
+
+// resourcesMutex is locked while a reload or a retrain swaps the resources of a locale, the replies
+// hold it for reading while they choose the intent and the response
+var resourcesMutex sync.RWMutex
+
+// tablesMutex is locked with resourcesMutex when the tables of the locales are replaced. The modules
+// hold it for reading instead of resourcesMutex since they can wait for a web service, and the
+// reloads and the retrains keep it locked to compile the contexts before they swap them.
+var tablesMutex sync.RWMutex
+
+// moduleClient is used by the modules which call a web service
+var moduleClient = &http.Client{Timeout: 10 * time.Second}
+
+// intentsMutex guards the intents cache which is also written when a network is trained
+var intentsMutex sync.RWMutex
+
+// ResourcesWatchInterval is the interval at which the files of the locale packs are checked for
+// changes, 0 disables the reloads
+var ResourcesWatchInterval = 2 * time.Second
+
+// RetrainOnReload retrains the network of a locale in the background when a reload changed its
+// patterns
+var RetrainOnReload = false
+
+var (
+	reloadEvents      []ReloadEvent
+	reloadEventsMutex sync.Mutex
+)
+
+var (
+	retrainMutex    sync.Mutex
+	retrainsRunning = map[string]bool{}
+	retrainsPending = map[string]bool{}
+)
 
 var JokesTag = "jokes"
 
 var modulesf = map[string][]Modulef{}
 
-var ReasonKeywords = map[string]ReasonKeyword{
-	"en": {
-		That: "that",
-		To:   "to",
-	},
-	// "de": {
-	// 	That: "das",
-	// 	To:   "zu",
-	// },
-	// "fr": {
-	// 	That: "que",
-	// 	To:   "de",
-	// },
-	// "es": {
-	// 	That: "que",
-	// 	To:   "para",
-	// },
-	// "ca": {
-	// 	That: "que",
-	// 	To:   "a",
-	// },
-	// "it": {
-	// 	That: "quel",
-	// 	To:   "per",
-	// },
-	// "tr": {
-	// 	That: "için",
-	// 	To:   "sebebiyle",
-	// },
-	// "nl": {
-	// 	That: "dat",
-	// 	To:   "naar",
-	// },
-	// "el": {
-	// 	That: "το οποίο",
-	// 	To:   "στο",
-	// },
-}
+// moduleReplacers holds the replacers of the modules by their tag, their patterns and responses
+// are given by the locale packs
+var moduleReplacers = map[string]ModuleReplacer{}
 
-var SpotifyKeyword = map[string]SpotifyKeywords{
-	"en": {
-		Play: "play",
-		From: "from",
-		On:   "on",
-	},
-	// "de": {
-	// 	Play: "spiele",
-	// 	From: "von",
-	// 	On:   "auf",
-	// },
-	// "fr": {
-	// 	Play: "joue",
-	// 	From: "de",
-	// 	On:   "sur",
-	// },
-	// "es": {
-	// 	Play: "Juega",
-	// 	From: "de",
-	// 	On:   "en",
-	// },
-	// "ca": {
-	// 	Play: "Juga",
-	// 	From: "de",
-	// 	On:   "a",
-	// },
-	// "it": {
-	// 	Play: "suona",
-	// 	From: "da",
-	// 	On:   "a",
-	// },
-	// "tr": {
-	// 	Play: "Başlat",
-	// 	From: "dan",
-	// 	On:   "kadar",
-	// },
-	// "nl": {
-	// 	Play: "speel",
-	// 	From: "van",
-	// 	On:   "op",
-	// },
-	// "el": {
-	// 	Play: "αναπαραγωγή",
-	// 	From: "από",
-	// 	On:   "στο",
-	// },
-}
+var ReasonKeywords = map[string]ReasonKeyword{}
+
+var SpotifyKeyword = map[string]SpotifyKeywords{}
 
 var (
 	modules []Module
@@ -176,155 +113,27 @@ var (
 )
 
 var (
-	// MoviesGenres initializes movies genres in different languages
-	MoviesGenres = map[string][]string{
-		"en": {
-			"Action", "Adventure", "Animation", "Children", "Comedy", "Crime", "Documentary", "Drama", "Fantasy",
-			"Film-Noir", "Horror", "Musical", "Mystery", "Romance", "Sci-Fi", "Thriller", "War", "Western",
-		},
-		// "de": {
-		// 	"Action", "Abenteuer", "Animation", "Kinder", "Komödie", "Verbrechen", "Dokumentarfilm", "Drama", "Fantasie",
-		// 	"Film-Noir", "Horror", "Musical", "Mystery", "Romance", "Sci-Fi", "Thriller", "Krieg", "Western",
-		// },
-		// "fr": {
-		// 	"Action", "Aventure", "Animation", "Enfant", "Comédie", "Crime", "Documentaire", "Drama", "Fantaisie",
-		// 	"Film-Noir", "Horreur", "Musical", "Mystère", "Romance", "Science-fiction", "Thriller", "Guerre", "Western",
-		// },
-		// "es": {
-		// 	"Acción", "Aventura", "Animación", "Infantil", "Comedia", "Crimen", "Documental", "Drama", "Fantasía",
-		// 	"Cine Negro", "Terror", "Musical", "Misterio", "Romance", "Ciencia Ficción", "Thriller", "Guerra", "Western",
-		// },
-		// "ca": {
-		// 	"Acció", "Aventura", "Animació", "Nen", "Comèdia", "Crim", "Documental", "Drama", "Fantasia",
-		// 	"Film-Noir", "Horror", "Musical", "Misteri", "Romanç", "Ciència-ficció", "Thriller", "War", "Western",
-		// },
-		// "it": {
-		// 	"Azione", "Avventura", "Animazione", "Bambini", "Commedia", "Poliziesco", "Documentario", "Dramma", "Fantasia",
-		// 	"Film-Noir", "Orrore", "Musical", "Mistero", "Romantico", "Fantascienza", "Giallo", "Guerra", "Western",
-		// },
-		// "nl": {
-		// 	"Actie", "Avontuur", "Animatie", "Kinderen", "Komedie", "Krimi", "Documentaire", "Drama", "Fantasie",
-		// 	"Film-Noir", "Horror", "Musical", "Mysterie", "Romantiek", "Sci-Fi", "Thriller", "Oorlog", "Western",
-		// },
-		// "el": {
-		// 	"Δράση", "Περιπέτεια", "Κινούμενα Σχέδια", "Παιδικά", "Κωμωδία", "Έγκλημα", "Ντοκιμαντέρ", "Δράμα", "Φαντασία",
-		// 	"Film-Noir", "Τρόμου", "Μουσική", "Μυστηρίου", "Ρομαντική", "Επιστημονική Φαντασία", "Θρίλλερ", "Πολέμου", "Western",
-		// },
-	}
-	movies = SerializeMovies()
+	// MoviesGenres are the movies genres of each locale, in the order of the English ones
+	MoviesGenres = map[string][]string{}
+	movies       = SerializeMovies()
 )
 
 var countries = SerializeCountries()
 
 var rules []Rule
 
-var RuleTranslations = map[string]RuleTranslation{
-	"en": {
-		DaysOfWeek: []string{
-			"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
-		},
-		Months: []string{
-			"january", "february", "march", "april", "may", "june", "july",
-			"august", "september", "october", "november", "december",
-		},
-		RuleToday:         `today|tonight`,
-		RuleTomorrow:      `(after )?tomorrow`,
-		RuleAfterTomorrow: "after",
-		RuleDayOfWeek:     `(next )?(monday|tuesday|wednesday|thursday|friday|saturday|sunday)`,
-		RuleNextDayOfWeek: "next",
-		RuleNaturalDate:   `january|february|march|april|may|june|july|august|september|october|november|december`,
-	},
-	// "de": {
-	// 	DaysOfWeek: []string{
-	// 		"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag", "Sonntag",
-	// 	},
-	// 	Months: []string{
-	// 		"Januar", "Februar", "Marsch", "April", "Mai", "Juni", "Juli",
-	// 		"August", "September", "Oktober", "November", "Dezember",
-	// 	},
-	// 	RuleToday:         `heute|abends`,
-	// 	RuleTomorrow:      `(nach )?tomorrow`,
-	// 	RuleAfterTomorrow: "nach",
-	// 	RuleDayOfWeek:     `(nächsten )?(Montag|Dienstag|Mittwoch|Donnerstag|Freitag|Samstag|Sonntag)`,
-	// 	RuleNextDayOfWeek: "nächste",
-	// 	RuleNaturalDate:   `Januar|Februar|März|April|Mai|Juli|Juli|August|September|Oktober|November|Dezember`,
-	// },
-	// "fr": {
-	// 	DaysOfWeek: []string{
-	// 		"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche",
-	// 	},
-	// 	Months: []string{
-	// 		"janvier", "février", "mars", "avril", "mai", "juin", "juillet",
-	// 		"août", "septembre", "octobre", "novembre", "décembre",
-	// 	},
-	// 	RuleToday:         `aujourd'hui|ce soir`,
-	// 	RuleTomorrow:      `(après )?demain`,
-	// 	RuleAfterTomorrow: "après",
-	// 	RuleDayOfWeek:     `(lundi|mardi|mecredi|jeudi|vendredi|samedi|dimanche)( prochain)?`,
-	// 	RuleNextDayOfWeek: "prochain",
-	// 	RuleNaturalDate:   `janvier|février|mars|avril|mai|juin|juillet|août|septembre|octobre|novembre|décembre`,
-	// },
-	// "es": {
-	// 	DaysOfWeek: []string{
-	// 		"lunes", "martes", "miercoles", "jueves", "viernes", "sabado", "domingo",
-	// 	},
-	// 	Months: []string{
-	// 		"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio",
-	// 		"agosto", "septiembre", "octubre", "noviembre", "diciembre",
-	// 	},
-	// 	RuleToday:         `hoy|esta noche`,
-	// 	RuleTomorrow:      `(pasado )?mañana`,
-	// 	RuleAfterTomorrow: "pasado",
-	// 	RuleDayOfWeek:     `(el )?(proximo )?(lunes|martes|miercoles|jueves|viernes|sabado|domingo))`,
-	// 	RuleNextDayOfWeek: "proximo",
-	// 	RuleNaturalDate:   `enero|febrero|marzo|abril|mayo|junio|julio|agosto|septiembre|octubre|noviembre|diciembre`,
-	// },
-	// "ca": {
-	// 	DaysOfWeek: []string{
-	// 		"dilluns", "dimarts", "dimecres", "dijous", "divendres", "dissabte", "diumenge",
-	// 	},
-	// 	Months: []string{
-	// 		"gener", "febrer", "març", "abril", "maig", "juny", "juliol",
-	// 		"agost", "setembre", "octubre", "novembre", "desembre",
-	// 	},
-	// 	RuleToday:         `avui|aquesta nit`,
-	// 	RuleTomorrow:      `((després )?(de )?demà`,
-	// 	RuleAfterTomorrow: "després",
-	// 	RuleDayOfWeek:     `(el )?(proper )?(dilluns|dimarts|dimecres|dijous|divendres|dissabte|diumenge))`,
-	// 	RuleNextDayOfWeek: "proper",
-	// 	RuleNaturalDate:   `gener|febrer|març|abril|maig|juny|juliol|agost|setembre|octubre|novembre|desembre`,
-	// },
-	// "nl": {
-	// 	DaysOfWeek: []string{
-	// 		"maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag", "zondag",
-	// 	},
-	// 	Months: []string{
-	// 		"januari", "februari", "maart", "april", "mei", "juni", "juli",
-	// 		"augustus", "september", "oktober", "november", "december",
-	// 	},
-	// 	RuleToday:         `vandaag|vanavond`,
-	// 	RuleTomorrow:      `(na )?morgen`,
-	// 	RuleAfterTomorrow: "na",
-	// 	RuleDayOfWeek:     `(volgende )?(maandag|dinsdag|woensdag|donderdag|vrijdag|zaterdag|zondag)`,
-	// 	RuleNextDayOfWeek: "volgende",
-	// 	RuleNaturalDate:   `januari|februari|maart|april|mei|juni|juli|augustus|september|oktober|november|december`,
-	// },
-	// "el": {
-	// 	DaysOfWeek: []string{
-	// 		"δευτέρα", "τρίτη", "τετάρτη", "πέμπτη", "παρασκευή", "σάββατο", "κυριακή",
-	// 	},
-	// 	Months: []string{
-	// 		"ιανουάριος", "φεβρουάριος", "μάρτιος", "απρίλιος", "μάιος", "ιούνιος", "ιούλιος",
-	// 		"αύγουστος", "σεπτέμβριος", "οκτώβριος", "νοέμβριος", "δεκέμβριος",
-	// 	},
-	// 	RuleToday:         `σήμερα|απόψε`,
-	// 	RuleTomorrow:      `(μεθ )?άυριο`,
-	// 	RuleAfterTomorrow: "μεθ",
-	// 	RuleDayOfWeek:     `(επόμενη )?(δευτέρα|τρίτη|τετάρτη|πέμπτη|παρασκευή|σάββατο|κυριακή)`,
-	// 	RuleNextDayOfWeek: "επόμενη",
-	// 	RuleNaturalDate:   `ιανουάριος|φεβρουάριος|μάρτιος|απρίλιος|μάιος|ιούνιος|ιούλιος|αύγουστος|σεπτέμβριος|οκτώβριος|νοέμβριος|δεκέμβριος`,
-	// },
-}
+// languageIdentifier is trained from the resources of the locales the first time a message is
+// received
+var languageIdentifier = sync.OnceValue(ReadLanguageIdentifier)
+
+// LanguageOverrideThreshold is the probability of the detected language from which it replaces
+// the locale requested by the client, 0 to always keep the requested locale
+var LanguageOverrideThreshold = 0.0
+
+// entityExtractors holds the extractors of the entities by their name
+var entityExtractors = map[string]EntityExtractor{}
+
+var RuleTranslations = map[string]RuleTranslation{}
 
 var daysOfWeek = map[string]time.Weekday{
 	"monday":    time.Monday,
@@ -336,57 +145,21 @@ var daysOfWeek = map[string]time.Weekday{
 	"sunday":    time.Sunday,
 }
 
-var PatternTranslation = map[string]PatternTranslations{
-	"en": {
-		DateRegex: `(of )?(the )?((after )?tomorrow|((today|tonight)|(next )?(monday|tuesday|wednesday|thursday|friday|saturday|sunday))|(\d{2}|\d)(th|rd|st|nd)? (of )?(january|february|march|april|may|june|july|august|september|october|november|december)|((\d{2}|\d)/(\d{2}|\d)))`,
-		TimeRegex: `(at )?(\d{2}|\d)(:\d{2}|\d)?( )?(pm|am|p\.m|a\.m)`,
-	},
-	// "de": {
-	// 	DateRegex: `(von )?(das )?((nach )?morgen|((heute|abends)|(nächsten )?(montag|dienstag|mittwoch|donnerstag|freitag|samstag|sonntag))|(\d{2}|\d)(th|rd|st|nd)? (of )?(januar|februar|märz|april|mai|juli|juli|august|september|oktober|november|dezember)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(um )?(\d{2}|\d)(:\d{2}|\d)?( )?(pm|am|p\.m|a\.m)`,
-	// },
-	// "fr": {
-	// 	DateRegex: `(le )?(après )?demain|((aujourd'hui'|ce soir)|(lundi|mardi|mecredi|jeudi|vendredi|samedi|dimanche)( prochain)?|(\d{2}|\d) (janvier|février|mars|avril|mai|juin|juillet|août|septembre|octobre|novembre|décembre)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(à )?(\d{2}|\d)(:\d{2}|\d)?( )?(pm|am|p\.m|a\.m)`,
-	// },
-	// "es": {
-	// 	DateRegex: `(el )?((pasado )?mañana|((hoy|esta noche)|(el )?(proximo )?(lunes|martes|miercoles|jueves|viernes|sabado|domingo))|(\d{2}|\d) (de )?(enero|febrero|marzo|abril|mayo|junio|julio|agosto|septiembre|octubre|noviembre|diciembre)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(a )?(las )?(\d{2}|\d)(:\d{2}|\d)?( )?(de )?(la )?(pm|am|p\.m|a\.m|tarde|mañana)`,
-	// },
-	// "ca": {
-	// 	DateRegex: `(el )?((després )?(de )?demà|((avui|aquesta nit)|(el )?(proper )?(dilluns|dimarts|dimecres|dijous|divendres|dissabte|diumenge))|(\d{2}|\d) (de )?(gener|febrer|març|abril|maig|juny|juliol|agost|setembre|octubre|novembre|desembre)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(a )?(les )?(\d{2}|\d)(:\d{2}|\d)?( )?(pm|am|p\.m|a\.m)`,
-	// },
-	// "nl": {
-	// 	DateRegex: `(van )?(de )?((na )?morgen|((vandaag|vanavond)|(volgende )?(maandag|dinsdag|woensdag|donderdag|vrijdag|zaterdag|zondag))|(\d{2}|\d)(te|de)? (vab )?(januari|februari|maart|april|mei|juni|juli|augustus|september|oktober|november|december)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(om )?(\d{2}|\d)(:\d{2}|\d)?( )?(pm|am|p\.m|a\.m)`,
-	// },
-	// "el": {
-	// 	DateRegex: `(από )?(το )?((μεθ )?αύριο|((σήμερα|απόψε)|(επόμενη )?(δευτέρα|τρίτη|τετάρτη|πέμπτη|παρασκευή|σάββατο|κυριακή))|(\d{2}|\d)(η)? (of )?(ιανουάριος|φεβρουάριος|μάρτιος|απρίλιος|μάιος|ιούνιος|ιούλιος|αύγουστος|σεπτέμβριος|οκτώβριος|νοέμβριος|δεκέμβριος)|((\d{2}|\d)/(\d{2}|\d)))`,
-	// 	TimeRegex: `(at )?(\d{2}|\d)(:\d{2}|\d)?( )?(μμ|πμ|μ\.μ|π\.μ)`,
-	// },
-}
+var PatternTranslation = map[string]PatternTranslations{}
 
 var fileName = "../res/authentication.txt"
 
 var authenticationHash []byte
 
-var MathDecimals = map[string]string{
-	"en": `(\d+( |-)decimal(s)?)|(number (of )?decimal(s)? (is )?\d+)`,
-	// "de": `(\d+( |-)decimal(s)?)|(nummer (von )?decimal(s)? (ist )?\d+)`,
-	// "fr": `(\d+( |-)decimale(s)?)|(nombre (de )?decimale(s)? (est )?\d+)`,
-	// "es": `(\d+( |-)decimale(s)?)|(numero (de )?decimale(s)? (de )?\d+)`,
-	// "ca": `(\d+( |-)decimal(s)?)|(nombre (de )?decimal(s)? (de )?\d+)`,
-	// "it": `(\d+( |-)decimale(s)?)|(numero (di )?decimale(s)? (è )?\d+)`,
-	// "tr": `(\d+( |-)desimal(s)?)|(numara (dan )?desimal(s)? (mı )?\d+)`,
-	// "nl": `(\d+( |-)decimal(en)?)|(nummer (van )?decimal(en)? (is )?\d+)`,
-	// "el": `(\d+( |-)δεκαδικ(ό|ά)?)|(αριθμός (από )?δεκαδικ(ό|ά)? (είναι )?\d+)`,
-}
+var MathDecimals = map[string]string{}
 
 var names = SerializeNames()
 
 var decimal = "\\b\\d+([\\.,]\\d+)?"
 
+// placeholderRegex finds the verbs of the responses given to fmt.Sprintf and the {slots}
+var placeholderRegex = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*(\d+|\*)?(\.(\d+|\*)?)?[a-zA-Z%]|\{\w+\}`)
+
 var (
 	redirectURL = os.Getenv("REDIRECT_URL")
 	callbackURL = os.Getenv("CALLBACK_URL")
@@ -446,10 +219,187 @@ var (
 
 var AreaTag = "area"
 
+// DefaultTrainingConfig is used to train the locales which aren't in TrainingConfigs
+var DefaultTrainingConfig = TrainingConfig{
+	HiddenLayers: []HiddenLayer{
+		{Nodes: 50, Activation: SigmoidActivation},
+	},
+	OutputActivation: SigmoidActivation,
+	// The mean squared error of the sigmoid
+	Loss:             "",
+	Rate:             0.1,
+	Optimizer:        SGDOptimizer,
+	BatchSize:        0,
+	Shuffle:          true,
+	Epochs:           200,
+	Patience:         0,
+	ValidationSplit:  0,
+	Dropout:          0,
+	WeightDecay:      0,
+	GradientClipping: 0,
+	Seed:             0,
+	Augmentation:     0,
+}
+
+// nlpContexts holds the compiled context of each locale
+var nlpContexts sync.Map
+
+// pipelineFactories builds the pipelines of the locales which don't use the default one
+var pipelineFactories = map[string]func(locale string) Pipeline{}
+
+// FoldAccents lists the locales whose sentences lose their accents before they are tokenized
+var FoldAccents = map[string]bool{}
+
+// stemmers holds the snowball stemmer of each language
+var stemmers sync.Map
+
+var (
+	punctuationAfterLetterRegex = regexp.MustCompile(`\p{L}( )?(\.|\?|!|¿|¡|。|？|！)`)
+	punctuationRegex            = regexp.MustCompile(`(\.|\?|!|。|？|！)`)
+	openingPunctuationRegex     = regexp.MustCompile(`[¿¡]`)
+	numberRegex                 = regexp.MustCompile(`-?\d+([.,]\d+)?`)
+)
+
+// The punctuation removed by the tokenization of the models saved in the full format
+var (
+	legacyPunctuationAfterLetterRegex = regexp.MustCompile(`[a-zA-Z]( )?(\.|\?|!|¿|¡)`)
+	legacyPunctuationRegex            = regexp.MustCompile(`(\.|\?|!)`)
+)
+
+// DefaultConfidenceThreshold is the score under which the best intent isn't trusted and the
+// "don't understand" message is sent, 0 uses the threshold of the loss the model was trained with
+var DefaultConfidenceThreshold = 0.0
+
+// LossConfidenceThresholds holds the confidence threshold of the models trained with each loss.
+// The sigmoid scores of the intents which don't match the sentence stay close to 0, while the
+// softmax spreads its probabilities over the intents which share words with it. The scores of the
+// models trained with the squared error, before the losses were saved, are all low.
+var LossConfidenceThresholds = map[string]float64{
+	BinaryCrossEntropyLoss: 0.15,
+	CrossEntropyLoss:       0.5,
+	MeanSquaredLoss:        0.004,
+}
+
+// ConfidenceThresholds overrides the confidence threshold for some locales
+var ConfidenceThresholds = map[string]float64{}
+
+// SpellingCorrection enables the correction of the misspelled words before they are stemmed
+var SpellingCorrection = false
+
+// DefaultSpellingBudget is the maximum number of edits to correct a word
+var DefaultSpellingBudget = 2
+
+// SpellingBudgets overrides the spelling budget for some locales, 0 disables the correction
+var SpellingBudgets = map[string]int{}
+
+// namesTree indexes ../res/datasets/names.txt for the spelling correction, it is only built when
+// it is enabled
+var namesTree = sync.OnceValue(func() *BKTree {
+	return NewBKTree(names...)
+})
+
+// keywordIndexes are the keywords of the locale packs, they are replaced with the pack
+var keywordIndexes = map[string]localeKeywords{}
+
+// ResponseRandom returns a random number in [0, n) to choose the responses, it can be replaced by
+// a seeded source to get predictable responses
+var ResponseRandom = rand.Intn
+
+var TrainingConfigs = map[string]TrainingConfig{}
+
+var (
+	ErrModelFormat   = errors.New("unknown model format")
+	ErrModelVersion  = errors.New("unsupported model version")
+	ErrModelChecksum = errors.New("the checksum doesn't match, the model is corrupted")
+
+	ErrMatrixShape    = errors.New("the shapes of the matrices don't match")
+	ErrMatrixAliasing = errors.New("the matrices share their values")
+
+	ErrNoNLPContext = errors.New("no NLP context was compiled")
+)
+
+// ActivationFunctions are the activations which can be used by the hidden layers
+var ActivationFunctions = map[string]Activation{
+	SigmoidActivation: {
+		Function:   Sigmoid,
+		Derivative: SigmoidDerivative,
+	},
+	TanhActivation: {
+		Function:   math.Tanh,
+		Derivative: TanhDerivative,
+	},
+	ReLUActivation: {
+		Function:   ReLU,
+		Derivative: ReLUDerivative,
+		He:         true,
+	},
+	LeakyReLUActivation: {
+		Function:   LeakyReLU,
+		Derivative: LeakyReLUDerivative,
+		He:         true,
+	},
+}
+
 // =================================================================
 const adviceURL = "https://api.adviceslip.com/advice"
 const day = time.Hour * 24
 const jokeURL = "https://official-joke-api.appspot.com/random_joke"
 const DontUnderstand = "don't understand"
 
+// reloadHistorySize is the number of reload events returned by the API
+const reloadHistorySize = 50
+
+const (
+	// ModelFormatVersion is the version of the saved models, the models saved before it existed
+	// have the version 0.
+	ModelFormatVersion = 2
+	// minimumBinaryModelVersion is the first version of the binary format with flat matrices
+	minimumBinaryModelVersion = 2
+	// modelMagic starts the models saved in the binary format
+	modelMagic = "OLVM"
+)
+
+const (
+	// dotProductBlockSize is the number of rows of the second matrix of a dot product kept in the
+	// cache at once
+	dotProductBlockSize = 64
+	// parallelThreshold is the number of multiplications from which a dot product is split between
+	// goroutines
+	parallelThreshold = 1 << 16
+
+	// distanceBufferSize is the number of characters under which the distances don't allocate
+	distanceBufferSize = 64
+	// jaroWinklerScaling is how much each character of the common prefix raises the similarity
+	jaroWinklerScaling = 0.1
+
+	// augmentationAttempts is the number of attempts for each variant of a pattern, the attempts
+	// which give an existing sentence are lost
+	augmentationAttempts = 4
+	// previewVariants is the number of variants printed for each pattern when the augmentation of
+	// the training is disabled
+	previewVariants = 3
+
+	// languageNGramSize is the length of the longest character n-grams of the language identifier
+	languageNGramSize = 3
+)
+
+const (
+	SigmoidActivation   = "sigmoid"
+	SoftmaxActivation   = "softmax"
+	TanhActivation      = "tanh"
+	ReLUActivation      = "relu"
+	LeakyReLUActivation = "leaky-relu"
+
+	MeanSquaredLoss        = "mse"
+	CrossEntropyLoss       = "cross-entropy"
+	BinaryCrossEntropyLoss = "binary-cross-entropy"
+
+	SGDOptimizer      = "sgd"
+	MomentumOptimizer = "momentum"
+	AdamOptimizer     = "adam"
+
+	POFormat    = "po"
+	XLIFFFormat = "xliff"
+)
+
 // =================================================================
@@ -12,6 +12,7 @@ require (
 	github.com/zmb3/spotify v1.3.0
 	golang.org/x/crypto v0.27.0
 	golang.org/x/oauth2 v0.23.0
+	golang.org/x/text v0.18.0
 	gopkg.in/cheggaaa/pb.v1 v1.0.28
 )
 
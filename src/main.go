@@ -5,9 +5,12 @@ package main
 
 // =================================================================
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/gookit/color"
@@ -30,13 +33,188 @@ const (
 func main() {
 	serverPortArg := flag.String("port", defaultPort, "The port for the API and WebSocket.")
 	localeRetrainArg := flag.String("re-train", "", "The locale(s) to re-train.")
+	localeCompactArg := flag.String("compact", "", "The locale(s) whose saved model is converted to the compact format.")
+	outputActivationArg := flag.String(
+		"output-activation",
+		olivia.SigmoidActivation,
+		"The activation of the output layer for the trained models: sigmoid or softmax.",
+	)
+	lossArg := flag.String(
+		"loss",
+		olivia.DefaultTrainingConfig.Loss,
+		"The loss of the output layer: mse or binary-cross-entropy for a sigmoid, cross-entropy for a softmax, empty for the one of the activation.",
+	)
+	hiddenLayersArg := flag.String(
+		"hidden-layers",
+		"50:sigmoid",
+		"The hidden layers as nodes:activation separated by commas, activations: sigmoid, tanh, relu or leaky-relu.",
+	)
+	rateArg := flag.Float64("rate", olivia.DefaultTrainingConfig.Rate, "The learning rate of the optimizer.")
+	optimizerArg := flag.String("optimizer", olivia.SGDOptimizer, "The optimizer used for training: sgd, momentum or adam.")
+	batchSizeArg := flag.Int("batch-size", olivia.DefaultTrainingConfig.BatchSize, "The number of samples per batch, 0 for the full training set.")
+	shuffleArg := flag.Bool("shuffle", true, "Shuffle the training samples at each epoch.")
+	epochsArg := flag.Int("epochs", olivia.DefaultTrainingConfig.Epochs, "The maximum number of training epochs.")
+	validationSplitArg := flag.Float64(
+		"validation-split",
+		0,
+		"The part of the patterns of each intent kept aside to validate the model, between 0 and 1.",
+	)
+	patienceArg := flag.Int(
+		"patience",
+		0,
+		"Stop the training when the validation loss hasn't improved for this number of epochs, 0 to disable.",
+	)
+	dropoutArg := flag.Float64("dropout", 0, "The probability of dropping each unit of the hidden layers while training.")
+	weightDecayArg := flag.Float64("weight-decay", 0, "The L2 weight decay applied at each step of the training.")
+	gradientClippingArg := flag.Float64(
+		"gradient-clipping",
+		0,
+		"The maximum norm of the adjustments of each training step, 0 to disable.",
+	)
+	confidenceThresholdArg := flag.Float64(
+		"confidence-threshold",
+		olivia.DefaultConfidenceThreshold,
+		"The confidence under which the \"don't understand\" message is sent, 0 for the one of the model's loss.",
+	)
+	spellingCorrectionArg := flag.Bool(
+		"spelling-correction",
+		false,
+		"Correct the misspelled words with the words of the intents before predicting.",
+	)
+	spellingBudgetArg := flag.Int(
+		"spelling-budget",
+		olivia.DefaultSpellingBudget,
+		"The maximum number of edits to correct a misspelled word.",
+	)
+	languageOverrideArg := flag.Float64(
+		"language-override",
+		0,
+		"The probability from which the detected language replaces the locale of the client, 0 to disable.",
+	)
+	augmentationArg := flag.Int(
+		"augmentation",
+		0,
+		"The maximum number of variants generated for each pattern before the training, 0 to disable.",
+	)
+	showAugmentedArg := flag.String(
+		"show-augmented",
+		"",
+		"Print the variants generated for the patterns of the locales separated by commas and exit, 3 by pattern when -augmentation is 0.",
+	)
+	watchResourcesArg := flag.Duration(
+		"watch-resources",
+		olivia.ResourcesWatchInterval,
+		"The interval at which the files of the locales are checked to reload them, 0 to disable.",
+	)
+	retrainOnReloadArg := flag.Bool(
+		"retrain-on-reload",
+		false,
+		"Retrain the network of a locale in the background when a reload changed its patterns.",
+	)
+	seedArg := flag.Int64("seed", 0, "The seed of the training, 0 for a random one which is saved with the model.")
 	flag.Parse()
 
+	// Check the optimizer and the layers before running anything
+	if _, err := olivia.NewOptimizer(*optimizerArg, *rateArg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err := olivia.OutputLoss(*outputActivationArg, *lossArg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *dropoutArg < 0 || *dropoutArg >= 1 {
+		fmt.Println("The dropout must be between 0 and 1.")
+		os.Exit(1)
+	}
+
+	hiddenLayers, err := olivia.ParseHiddenLayers(*hiddenLayersArg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	olivia.DefaultTrainingConfig.HiddenLayers = hiddenLayers
+	olivia.DefaultTrainingConfig.OutputActivation = *outputActivationArg
+	olivia.DefaultTrainingConfig.Loss = *lossArg
+	olivia.DefaultTrainingConfig.Rate = *rateArg
+	olivia.DefaultTrainingConfig.Optimizer = *optimizerArg
+	olivia.DefaultTrainingConfig.BatchSize = *batchSizeArg
+	olivia.DefaultTrainingConfig.Shuffle = *shuffleArg
+	olivia.DefaultTrainingConfig.Epochs = *epochsArg
+	olivia.DefaultTrainingConfig.ValidationSplit = *validationSplitArg
+	olivia.DefaultTrainingConfig.Patience = *patienceArg
+	olivia.DefaultTrainingConfig.Dropout = *dropoutArg
+	olivia.DefaultTrainingConfig.WeightDecay = *weightDecayArg
+	olivia.DefaultTrainingConfig.GradientClipping = *gradientClippingArg
+	olivia.DefaultTrainingConfig.Seed = *seedArg
+	olivia.DefaultTrainingConfig.Augmentation = *augmentationArg
+	olivia.DefaultConfidenceThreshold = *confidenceThresholdArg
+	olivia.SpellingCorrection = *spellingCorrectionArg
+	olivia.DefaultSpellingBudget = *spellingBudgetArg
+	olivia.LanguageOverrideThreshold = *languageOverrideArg
+	olivia.ResourcesWatchInterval = *watchResourcesArg
+	olivia.RetrainOnReload = *retrainOnReloadArg
+
+	// Load the locales, their tables and their modules from the locale packs of ../res/locales
+	if err := olivia.LoadLocalePacks(); err != nil {
+		fmt.Println(color.FgRed.Render(err.Error()))
+		os.Exit(1)
+	}
+
+	// The subcommands train and predict with the flags above
+	switch flag.Arg(0) {
+	// Convert a model between the JSON and the binary formats
+	case "convert":
+		executeModelConversion(flag.Args()[1:])
+		return
+	// Evaluate the intents of locales with a k-fold cross-validation
+	case "cross-validate":
+		executeCrossValidation(flag.Args()[1:])
+		return
+	// Compare the translations of the locales with the English resources
+	case "coverage":
+		executeCoverage(flag.Args()[1:])
+		return
+	// Exchange the messages and the intents with the translators in the PO or XLIFF formats
+	case "export":
+		executeTranslationExport(flag.Args()[1:])
+		return
+	case "import":
+		executeTranslationImport(flag.Args()[1:])
+		return
+	}
+
+	// Print the augmented patterns for the authors of the intents
+	if *showAugmentedArg != "" {
+		for _, individualLocale := range strings.Split(*showAugmentedArg, ",") {
+			err := olivia.PrintAugmentation(individualLocale, olivia.GetTrainingConfig(individualLocale).Augmentation)
+			if err != nil {
+				fmt.Println(color.FgRed.Render(err.Error()))
+				os.Exit(1)
+			}
+		}
+
+		return
+	}
+
 	// If the localeRetrainArg isn't empty then retrain the given models
 	if *localeRetrainArg != "" {
 		executeModelRetraining(*localeRetrainArg)
 	}
 
+	// Convert the models saved with their training matrices
+	if *localeCompactArg != "" {
+		for _, individualLocale := range strings.Split(*localeCompactArg, ",") {
+			modelPath := olivia.ModelPath(individualLocale)
+			if err := olivia.ConvertNetwork(modelPath, modelPath); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+
 	// Print the Olivia ASCII text
 	oliviaASCIIBanner := string(olivia.FetchFileContent("../res/olivia-ascii.txt"))
 	fmt.Println(color.FgLightGreen.Render(oliviaASCIIBanner))
@@ -57,11 +235,193 @@ func main() {
 	olivia.StartServer(neuralNetworksMapContainer, *serverPortArg)
 }
 
+func executeModelConversion(arguments []string) {
+	if len(arguments) != 2 {
+		fmt.Println("Usage: convert <input model> <output model>, a .json output is saved in JSON and any other in binary.")
+		os.Exit(2)
+	}
+
+	if err := olivia.ConvertNetwork(arguments[0], arguments[1]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Converted %s to %s.\n", arguments[0], arguments[1])
+}
+
+func executeCrossValidation(arguments []string) {
+	flags := flag.NewFlagSet("cross-validate", flag.ExitOnError)
+	foldsArg := flags.Int("folds", 5, "The number of folds.")
+	jsonArg := flags.Bool("json", false, "Print the report in JSON instead of tables.")
+	flags.Usage = func() {
+		fmt.Println("Usage: cross-validate [-folds k] [-json] <locale(s)>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	if flags.NArg() != 1 || *foldsArg < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var reports []olivia.CrossValidationReport
+	for _, individualLocale := range strings.Split(flags.Arg(0), ",") {
+		// Keep the progress of the training out of the JSON report
+		if *jsonArg {
+			stdout := os.Stdout
+			os.Stdout = os.Stderr
+			reports = append(reports, olivia.CrossValidate(individualLocale, *foldsArg))
+			os.Stdout = stdout
+			continue
+		}
+
+		olivia.CrossValidate(individualLocale, *foldsArg).Print(os.Stdout)
+	}
+
+	if *jsonArg {
+		bytes, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(bytes))
+	}
+}
+
+func executeCoverage(arguments []string) {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	jsonArg := flags.Bool("json", false, "Print the report in JSON instead of tables.")
+	flags.Usage = func() {
+		fmt.Println("Usage: coverage [-json] [locale(s)]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	for _, individualLocale := range olivia.Locales {
+		olivia.GenerateSerializedMessages(individualLocale.Tag)
+		olivia.SerializeIntents(individualLocale.Tag)
+	}
+
+	// Keep the locales asked for, all of them by default
+	var reports []olivia.LocaleCoverage
+	for _, coverage := range olivia.ComputeCoverage() {
+		if flags.NArg() == 0 || slices.Contains(strings.Split(flags.Arg(0), ","), coverage.Tag) {
+			reports = append(reports, coverage)
+		}
+	}
+
+	if *jsonArg {
+		bytes, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("There is no translation to compare with the English resources.")
+	}
+	for _, coverage := range reports {
+		coverage.Print(os.Stdout)
+	}
+}
+
+// translationFormat returns the format given to the command or the one of the file's extension
+func translationFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+
+	switch filepath.Ext(path) {
+	case ".xlf", ".xliff":
+		return olivia.XLIFFFormat
+	}
+
+	return olivia.POFormat
+}
+
+func executeTranslationExport(arguments []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatArg := flags.String("format", "", "The format of the translations: po or xliff, given by the extension by default.")
+	flags.Usage = func() {
+		fmt.Println("Usage: export [-format po|xliff] <locale> [output], the translations are printed without output.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	content, err := olivia.ExportTranslations(flags.Arg(0), translationFormat(*formatArg, flags.Arg(1)))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if flags.NArg() == 1 {
+		os.Stdout.Write(content)
+		return
+	}
+
+	if err := os.WriteFile(flags.Arg(1), content, 0644); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func executeTranslationImport(arguments []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	formatArg := flags.String("format", "", "The format of the translations: po or xliff, given by the extension by default.")
+	jsonArg := flags.Bool("json", false, "Print the report in JSON instead of lists.")
+	flags.Usage = func() {
+		fmt.Println("Usage: import [-format po|xliff] [-json] <locale> <input>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(arguments)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	content, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	report, err := olivia.ImportTranslations(flags.Arg(0), translationFormat(*formatArg, flags.Arg(1)), content)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *jsonArg {
+		bytes, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(bytes))
+		return
+	}
+
+	for _, entries := range []struct {
+		title string
+		keys  []string
+	}{
+		{"Updated", report.Updated},
+		{"Missing", report.Missing},
+		{"Stale, not imported", report.Stale},
+	} {
+		fmt.Printf("%s: %d\n", color.FgBlue.Render(entries.title), len(entries.keys))
+		for _, key := range entries.keys {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+}
+
 func executeModelRetraining(localeRetrainList string) {
 	// Iterate locales by separating them by comma
 	for _, individualLocale := range strings.Split(localeRetrainList, ",") {
-		trainingFilePath := fmt.Sprintf("../res/locales/%s/training.json", individualLocale)
-		deleteError := os.Remove(trainingFilePath)
+		deleteError := os.Remove(olivia.ModelPath(individualLocale))
 
 		if deleteError != nil {
 			fmt.Printf("Cannot re-train %s model.", individualLocale)